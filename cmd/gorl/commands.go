@@ -0,0 +1,415 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/learn"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// Exit codes shared by all commands
+const (
+	exitOK      = 0
+	exitFailure = 1 // the command ran but did not succeed
+	exitUsage   = 2 // the command line was malformed
+)
+
+// A subcommand of the gorl binary
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []command{
+	{"train", "learn a policy and save it", trainCommand},
+	{"eval", "run greedy episodes with a saved policy and summarize them", evalCommand},
+	{"run", "play one greedy episode with a saved policy, tracing each step", runCommand},
+	{"inspect", "print Q-values and greedy actions of a saved policy", inspectCommand},
+	{"sweep", "train over a grid of parameter values and seeds in parallel", sweepCommand},
+}
+
+// parse the flags of a command, returning the exit code to use if parsing
+// did not succeed
+func parseFlags(fs *flag.FlagSet, args []string) (ok bool, code int) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return false, exitOK
+		}
+		return false, exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "gorl %v: unexpected arguments %v\n", fs.Name(), fs.Args())
+		fs.Usage()
+		return false, exitUsage
+	}
+	return true, exitOK
+}
+
+// report a missing required flag
+func missingFlag(fs *flag.FlagSet, name string) int {
+	fmt.Fprintf(os.Stderr, "gorl %v: the -%v flag is required\n", fs.Name(), name)
+	fs.Usage()
+	return exitUsage
+}
+
+// report an error encountered while running a command
+func commandFailed(name string, err error) int {
+	fmt.Fprintf(os.Stderr, "gorl %v: %v\n", name, err)
+	return exitFailure
+}
+
+// a repeatable flag holding "section.key=value" overrides
+type overrideList []string
+
+func (ol *overrideList) String() string {
+	return strings.Join(*ol, " ")
+}
+
+func (ol *overrideList) Set(value string) error {
+	if err := config.New().Override(value); err != nil {
+		return err
+	}
+	*ol = append(*ol, value)
+	return nil
+}
+
+// The flags selecting the configuration of a run, shared by the commands
+// that build an environment and learner
+type configFlags struct {
+	file      string
+	overrides overrideList
+	show      bool
+	save      string
+}
+
+// add the configuration flags to a command; show sets whether the effective
+// configuration is printed unless -show-config says otherwise
+func addConfigFlags(fs *flag.FlagSet, show bool) *configFlags {
+	cf := &configFlags{}
+	fs.StringVar(&cf.file, "conf", "", "A configuration file defining parameters of the run (default: built-in defaults only).")
+	fs.Var(&cf.overrides, "set", "Override a parameter, as section.key=value (repeatable).")
+	fs.BoolVar(&cf.show, "show-config", show, "Print the effective configuration before running.")
+	fs.StringVar(&cf.save, "save-config", "", "File to which the effective configuration will be written.")
+	return cf
+}
+
+// read the configuration file, if any, apply the overrides, and validate the
+// result, which also holds the built-in defaults of parameters it does not set
+func (cf *configFlags) load() (cfg *config.Config, err error) {
+	source := "built-in defaults"
+	if cf.file != "" {
+		if cfg, err = config.Load(cf.file); err != nil {
+			return
+		}
+		source = cf.file
+	} else {
+		cfg = config.New()
+	}
+	for _, setting := range cf.overrides {
+		if err = cfg.Override(setting); err != nil {
+			return
+		}
+	}
+	if len(cf.overrides) > 0 {
+		source += " with overrides"
+	}
+	if err = validateConfig(cfg); err != nil {
+		err = fmt.Errorf("invalid configuration (%v):\n%v", source, err)
+	}
+	return
+}
+
+// build the configuration of a run and the environment and learner it
+// describes. A seed taken from the clock and the default state_grid are
+// recorded in the configuration, so the effective configuration is enough to
+// repeat the run.
+func setup(name string, cf *configFlags) (cfg *config.Config, env environment.Environment, lrn learn.Learner, err error) {
+	if cfg, err = cf.load(); err != nil {
+		return
+	}
+	seed, configured, err := random.RunSeed(cfg)
+	if err != nil {
+		return
+	}
+	if !configured {
+		fmt.Fprintf(os.Stderr, "gorl %v: no seed configured, using seed %v\n", name, seed)
+		cfg.Set("learning", "seed", strconv.FormatInt(seed, 10))
+	}
+	recordStateGrid(cfg)
+	if cf.show {
+		fmt.Println("# effective configuration")
+		if err = config.WriteEffective(os.Stdout, cfg); err != nil {
+			return
+		}
+		fmt.Println()
+	}
+	if cf.save != "" {
+		if err = saveEffectiveConfig(cf.save, cfg); err != nil {
+			return
+		}
+	}
+	env, lrn, err = build(cfg, seed)
+	return
+}
+
+// build and initialize the environment and learner described by a validated
+// configuration, each with its own generator seeded from the run's seed
+func build(cfg *config.Config, seed int64) (env environment.Environment, lrn learn.Learner, err error) {
+	if env, err = environment.Create(cfg, random.NewRand(random.StreamSeed(seed, random.EnvironmentStream))); err != nil {
+		return
+	}
+	if lrn, err = learn.Create(cfg, random.NewRand(random.StreamSeed(seed, random.LearnerStream))); err != nil {
+		return
+	}
+	err = lrn.Init(cfg, env)
+	return
+}
+
+// write the effective configuration to a file
+func saveEffectiveConfig(filename string, cfg *config.Config) (err error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("error saving configuration: %v", err)
+	}
+	defer closeOnReturn(f, &err)
+	return config.WriteEffective(f, cfg)
+}
+
+// gorl train: learn a policy and save it
+func trainCommand(args []string) int {
+	fs := flag.NewFlagSet("train", flag.ContinueOnError)
+	cf := addConfigFlags(fs, true)
+	saveFile := fs.String("save", "policy.json", "File to which the learned policy will be written.")
+	policyFile := fs.String("policy", "", "File containing a saved policy that will be used to initialize the learner.")
+	resumeFile := fs.String("resume", "", "Checkpoint file from which to resume an interrupted training run.")
+	follow := fs.Bool("follow", false, "Follow the learned policy for an episode after training, printing each step.")
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	_, env, lrn, err := setup(fs.Name(), cf)
+	if err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	if *policyFile != "" {
+		if err = lrn.LoadPolicy(*policyFile); err != nil {
+			return commandFailed(fs.Name(), err)
+		}
+	}
+	if *resumeFile != "" {
+		c, ok := lrn.(learn.Checkpointer)
+		if !ok {
+			return commandFailed(fs.Name(), errors.New("the selected learner does not support checkpoints"))
+		}
+		if err = c.LoadCheckpoint(*resumeFile, env); err != nil {
+			return commandFailed(fs.Name(), err)
+		}
+	}
+	if err = lrn.Learn(env); err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	if err = lrn.SavePolicy(*saveFile); err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	if *follow {
+		lrn.FollowPolicy(env)
+	}
+	return exitOK
+}
+
+// gorl eval: run greedy episodes with a saved policy and summarize them
+func evalCommand(args []string) int {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	cf := addConfigFlags(fs, false)
+	policyFile := fs.String("policy", "", "File containing the saved policy to evaluate.")
+	episodes := fs.Uint("episodes", 0, "Number of episodes to run (default: episodes from the [evaluation] section).")
+	epsilon := fs.Float64("epsilon", -1, "Probability of taking a random action (default: epsilon from the [evaluation] section).")
+	steps := fs.Uint("max-steps", 0, fmt.Sprintf("Step limit per episode (default: max_steps from the configuration, or %v).", metrics.DefaultMaxSteps))
+	seed := fs.Int64("seed", 0, "Seed from which the episode seeds are derived (default: derived from the run's seed).")
+	verbose := fs.Bool("v", false, "Print the result of every episode.")
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *policyFile == "" {
+		return missingFlag(fs, "policy")
+	}
+	if *epsilon > 1 {
+		fmt.Fprintf(os.Stderr, "gorl %v: -epsilon must be at most 1\n", fs.Name())
+		return exitUsage
+	}
+
+	cfg, env, lrn, err := setup(fs.Name(), cf)
+	if err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	if err = lrn.LoadPolicy(*policyFile); err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+
+	ev, err := metrics.CreateEvaluator(cfg, false)
+	if err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	defer ev.Close()
+	if *episodes > 0 {
+		ev.Episodes = *episodes
+	}
+	if *epsilon >= 0 {
+		ev.Epsilon = *epsilon
+	}
+	if *steps > 0 {
+		ev.MaxSteps = *steps
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			ev.Seed = *seed
+		}
+	})
+	if *verbose {
+		ev.Log = os.Stdout
+	}
+	fmt.Println(ev.Evaluate(env, lrn))
+	return exitOK
+}
+
+// gorl run: play one episode with a saved policy, tracing each step. The
+// policy is greedy, unless the learner's policy is stochastic and follow =
+// sample.
+func runCommand(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	cf := addConfigFlags(fs, false)
+	policyFile := fs.String("policy", "", "File containing the saved policy to follow.")
+	steps := fs.Uint("max-steps", 0, fmt.Sprintf("Step limit for the episode (default: max_steps from the configuration, or %v).", metrics.DefaultMaxSteps))
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *policyFile == "" {
+		return missingFlag(fs, "policy")
+	}
+
+	cfg, env, lrn, err := setup(fs.Name(), cf)
+	if err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	if err = lrn.LoadPolicy(*policyFile); err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	limit := *steps
+	if limit == 0 {
+		limit = metrics.EpisodeLimit(cfg)
+	}
+	ep := metrics.RunEpisode(env, learn.Follow(lrn), limit, os.Stdout)
+	fmt.Printf("episode ended after %v steps with return %v (%v)\n", ep.Steps, ep.Return, ep.Outcome)
+	return exitOK
+}
+
+// a repeatable flag holding state vectors given as space-separated numbers
+type stateList []space.State
+
+func (sl *stateList) String() string {
+	return fmt.Sprint(*sl)
+}
+
+func (sl *stateList) Set(value string) error {
+	tokens := strings.Fields(value)
+	if len(tokens) == 0 {
+		return errors.New("empty state")
+	}
+	s := space.MakeState(uint(len(tokens)))
+	for i := range tokens {
+		val, err := strconv.ParseFloat(tokens[i], 64)
+		if err != nil {
+			return err
+		}
+		s.Vals[i] = val
+	}
+	*sl = append(*sl, s)
+	return nil
+}
+
+// gorl inspect: print Q-values and greedy actions of a saved policy, along
+// with any further tables the learner kept, or the weights of a learner
+// using function approximation
+func inspectCommand(args []string) int {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	policyFile := fs.String("policy", "", "File containing the saved policy to inspect.")
+	var states stateList
+	fs.Var(&states, "state", "A state to look up, as space-separated feature values (repeatable; default: every lattice state).")
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *policyFile == "" {
+		return missingFlag(fs, "policy")
+	}
+
+	p, err := learn.ReadPolicy(*policyFile)
+	if err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	if !p.HasLattice() {
+		// the weights of a learner using function approximation can only be
+		// interpreted by the learner, and so are printed as they are
+		if len(states) > 0 {
+			return commandFailed(fs.Name(), fmt.Errorf("policy learned by %v has no state lattice to look states up in", p.Learner))
+		}
+		if len(p.Actions) == 0 {
+			fmt.Printf("policy learned by %v: no state lattice, continuous actions\n", p.Learner)
+		} else {
+			fmt.Printf("policy learned by %v: no state lattice, %v actions %v\n", p.Learner, len(p.Actions), actionValues(p.Actions))
+		}
+		for _, name := range p.WeightNames() {
+			fmt.Printf("%v:\n", name)
+			for i, row := range p.Weights[name] {
+				fmt.Printf("    %v: %v\n", i, row)
+			}
+		}
+		return exitOK
+	}
+	if len(states) == 0 {
+		states = p.States
+	}
+	for _, s := range states {
+		if err = p.CheckFeatures(len(s.Vals)); err != nil {
+			return commandFailed(fs.Name(), fmt.Errorf("state %v: %v", s.Vals, err))
+		}
+	}
+
+	fmt.Printf("policy learned by %v: %v states, %v actions %v\n", p.Learner, len(p.States), len(p.Actions), actionValues(p.Actions))
+	for _, s := range states {
+		nearest, q, greedy := p.Lookup(s)
+		fmt.Printf("state %v -> lattice state %v %v: Q = %v, greedy action %v\n",
+			s.Vals, nearest.Id, nearest.Vals, q, p.Actions[greedy].Val)
+		for _, name := range p.TableNames() {
+			fmt.Printf("    %v = %v\n", name, p.Tables[name][nearest.Id])
+		}
+	}
+	return exitOK
+}
+
+// the values of a set of actions
+func actionValues(actions []space.Action) []float64 {
+	vals := make([]float64, len(actions))
+	for i := range actions {
+		vals[i] = actions[i].Val
+	}
+	return vals
+}
+
+// close c when a function returns, reporting the error from Close if the
+// function had not already failed
+func closeOnReturn(c io.Closer, err *error) {
+	if cerr := c.Close(); *err == nil {
+		*err = cerr
+	}
+}
@@ -0,0 +1,39 @@
+// Command gorl trains, evaluates, and inspects reinforcement learning agents
+// described by configuration files.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: gorl <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8v %v\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Run 'gorl <command> -h' for the flags of a command.")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
+	}
+	name := os.Args[1]
+	for _, c := range commands {
+		if c.name == name {
+			os.Exit(c.run(os.Args[2:]))
+		}
+	}
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage()
+		os.Exit(exitOK)
+	}
+	fmt.Fprintf(os.Stderr, "gorl: unknown command '%v'\n\n", name)
+	usage()
+	os.Exit(exitUsage)
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+)
+
+// A sweep trains a learner once for every combination of the values given
+// for a number of parameters, and for each of a number of seeds, and
+// summarizes how well each combination performed.
+//
+// The values are given in a sweep specification, which has the same format
+// as a configuration file. Each key lists the values its parameter takes,
+// separated by commas (or, for parameters holding a single number or name,
+// by whitespace). A numeric value may also be a range start:step:stop,
+// including stop if the steps reach it:
+//
+//	[learning]
+//	alpha = 0.1:0.1:0.5
+//	lambda = 0 0.5 0.9
+//
+//	[environment]
+//	state_grid = 3 3 3 3, 5 5 5 5
+//	action_grid = 3:2:9
+
+// One dimension of a sweep: a parameter and the values it takes
+type SweepAxis struct {
+	Section string
+	Key     string
+	Values  []string
+}
+
+func (ax *SweepAxis) Name() string {
+	return ax.Section + "." + ax.Key
+}
+
+// read the axes of a sweep from a specification, reporting every problem
+// with it at once
+func ParseSweepSpec(spec *config.Config) ([]SweepAxis, error) {
+	var axes []SweepAxis
+	var errs []error
+	for _, sec := range spec.Sections() {
+		for _, key := range spec.Keys(sec) {
+			p := config.Find(sec, key)
+			if p == nil {
+				errs = append(errs, &config.ParamError{Section: sec, Key: key, Err: errors.New("unknown parameter")})
+				continue
+			}
+			if sec == "learning" && key == "seed" {
+				errs = append(errs, &config.ParamError{Section: sec, Key: key, Err: errors.New("seeds are swept with the -seeds flag")})
+				continue
+			}
+			if sec == "metrics" {
+				errs = append(errs, &config.ParamError{Section: sec, Key: key, Err: errors.New("metrics are set by the sweep")})
+				continue
+			}
+			raw, _ := spec.String(sec, key)
+			values, err := parseSweepValues(p, raw)
+			if err != nil {
+				errs = append(errs, &config.ParamError{Section: sec, Key: key, Err: err})
+				continue
+			}
+			axes = append(axes, SweepAxis{sec, key, values})
+		}
+	}
+	if len(axes) == 0 && len(errs) == 0 {
+		errs = append(errs, errors.New("the sweep specification gives no parameters"))
+	}
+	return axes, errors.Join(errs...)
+}
+
+// split the values given for one parameter, expanding ranges
+func parseSweepValues(p *config.Parameter, raw string) ([]string, error) {
+	items := strings.Split(raw, ",")
+	if p.Kind != config.IntListParam {
+		items = strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	}
+	var values []string
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, fmt.Errorf("empty value in '%v'", raw)
+		}
+		if strings.Contains(item, ":") && (p.Kind == config.IntParam || p.Kind == config.UintParam || p.Kind == config.FloatParam) {
+			r, err := expandRange(item, p.Kind != config.FloatParam)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, r...)
+		} else {
+			values = append(values, item)
+		}
+	}
+	return values, nil
+}
+
+// expand a range start:step:stop into its values
+func expandRange(item string, integer bool) ([]string, error) {
+	parts := strings.Split(item, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("range '%v' is not of the form start:step:stop", item)
+	}
+	var x [3]float64
+	for i := range parts {
+		var err error
+		if integer {
+			var n int
+			n, err = strconv.Atoi(strings.TrimSpace(parts[i]))
+			x[i] = float64(n)
+		} else {
+			x[i], err = strconv.ParseFloat(strings.TrimSpace(parts[i]), 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("range '%v' has a malformed bound or step '%v'", item, parts[i])
+		}
+	}
+	start, step, stop := x[0], x[1], x[2]
+	if step == 0 || (stop-start)/step < 0 {
+		return nil, fmt.Errorf("range '%v' does not step from %v towards %v", item, start, stop)
+	}
+	// allow for rounding when the steps should reach stop exactly
+	n := int(math.Floor((stop-start)/step+1e-9)) + 1
+	values := make([]string, n)
+	for i := range values {
+		v := start + float64(i)*step
+		if integer {
+			values[i] = strconv.Itoa(int(v))
+		} else {
+			v, _ = strconv.ParseFloat(strconv.FormatFloat(v, 'g', 12, 64), 64)
+			values[i] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	}
+	return values, nil
+}
+
+// return every combination of the values of the axes, as indices into their
+// values, with the last axis varying fastest
+func sweepCombinations(axes []SweepAxis) [][]int {
+	combos := [][]int{{}}
+	for _, ax := range axes {
+		next := make([][]int, 0, len(combos)*len(ax.Values))
+		for _, c := range combos {
+			for i := range ax.Values {
+				next = append(next, append(append([]int{}, c...), i))
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// One training run of a sweep
+type sweepRun struct {
+	combo int
+	seed  int64
+	cfg   *config.Config
+}
+
+// The performance of one training run, from the returns of its episodes
+type sweepOutcome struct {
+	run   *sweepRun
+	final float64 // mean return over the last episodes
+	auc   float64 // mean return over all episodes: the area under the learning curve per episode
+	err   error
+}
+
+// train the learner of one run, reading its learning curve back from its
+// metrics file
+func (run *sweepRun) train(finalEpisodes uint) (out sweepOutcome) {
+	out.run = run
+	env, lrn, err := build(run.cfg, run.seed)
+	if err == nil {
+		err = lrn.Learn(env)
+	}
+	var returns []float64
+	if err == nil {
+		filename, _ := run.cfg.String("metrics", "file")
+		returns, err = readReturns(filename)
+	}
+	if err == nil && len(returns) == 0 {
+		err = errors.New("no episodes were recorded")
+	}
+	if err != nil {
+		out.err = err
+		return
+	}
+	n := len(returns)
+	first := 0
+	if uint(n) > finalEpisodes {
+		first = n - int(finalEpisodes)
+	}
+	for i, r := range returns {
+		out.auc += r
+		if i >= first {
+			out.final += r
+		}
+	}
+	out.auc /= float64(n)
+	out.final /= float64(n - first)
+	return
+}
+
+// read the episode returns from a metrics file written in JSON Lines format
+func readReturns(filename string) ([]float64, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var returns []float64
+	dec := json.NewDecoder(f)
+	for {
+		var rec metrics.EpisodeRecord
+		if err = dec.Decode(&rec); err == io.EOF {
+			return returns, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading %v: %v", filename, err)
+		}
+		returns = append(returns, rec.Return)
+	}
+}
+
+// The summarized performance of one combination of parameter values over
+// the seeds it was trained with
+type SweepResult struct {
+	Values []string
+	Runs   int // runs that completed
+	Failed int
+	Final  metrics.Summary
+	AUC    metrics.Summary
+}
+
+// gorl sweep: train over a grid of parameter values and seeds in parallel
+func sweepCommand(args []string) int {
+	fs := flag.NewFlagSet("sweep", flag.ContinueOnError)
+	cf := addConfigFlags(fs, false)
+	specFile := fs.String("spec", "", "A sweep specification listing the values each swept parameter takes.")
+	seeds := fs.Uint("seeds", 1, "Number of seeds with which each combination is trained, counting up from the run's seed.")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of runs trained at once.")
+	outDir := fs.String("out", "sweep", "Directory receiving the metrics of every run and the results table.")
+	finalEpisodes := fs.Uint("final", 10, "Number of last episodes whose mean return gives the final performance of a run.")
+	if ok, code := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *specFile == "" {
+		return missingFlag(fs, "spec")
+	}
+	if *seeds == 0 || *workers < 1 || *finalEpisodes == 0 {
+		fmt.Fprintf(os.Stderr, "gorl %v: -seeds, -workers and -final must be positive\n", fs.Name())
+		return exitUsage
+	}
+
+	base, err := cf.load()
+	if err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	spec, err := config.Load(*specFile)
+	if err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	axes, err := ParseSweepSpec(spec)
+	if err != nil {
+		return commandFailed(fs.Name(), fmt.Errorf("invalid sweep specification in %v:\n%v", *specFile, err))
+	}
+	seed, configured, err := random.RunSeed(base)
+	if err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	if !configured {
+		fmt.Fprintf(os.Stderr, "gorl %v: no seed configured, using seed %v\n", fs.Name(), seed)
+		base.Set("learning", "seed", strconv.FormatInt(seed, 10))
+	}
+	if err = os.MkdirAll(*outDir, 0755); err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	if cf.show {
+		fmt.Println("# effective base configuration")
+		config.WriteEffective(os.Stdout, base)
+		fmt.Println()
+	}
+	if cf.save != "" {
+		if err = saveEffectiveConfig(cf.save, base); err != nil {
+			return commandFailed(fs.Name(), err)
+		}
+	}
+
+	// build and validate the configuration of every run before starting any
+	combos := sweepCombinations(axes)
+	var runs []*sweepRun
+	var errs []error
+	for ci, combo := range combos {
+		cfg := base.Clone()
+		for ai, vi := range combo {
+			cfg.Set(axes[ai].Section, axes[ai].Key, axes[ai].Values[vi])
+		}
+		if err = validateConfig(cfg); err != nil {
+			errs = append(errs, fmt.Errorf("combination %v: %v", sweepLabel(axes, combo), err))
+			continue
+		}
+		recordStateGrid(cfg)
+		for si := int64(0); si < int64(*seeds); si++ {
+			run := &sweepRun{combo: ci, seed: seed + si, cfg: cfg.Clone()}
+			prefix := filepath.Join(*outDir, fmt.Sprintf("run-%04d-seed-%v", ci+1, run.seed))
+			run.cfg.Set("learning", "seed", strconv.FormatInt(run.seed, 10))
+			run.cfg.Set("metrics", "format", "jsonl")
+			run.cfg.Set("metrics", "file", prefix+".jsonl")
+			run.cfg.Set("metrics", "log_interval", "0")
+			run.cfg.Set("learning", "checkpoint_file", prefix+"-checkpoint.json")
+			run.cfg.Set("evaluation", "file", prefix+"-eval.csv")
+			runs = append(runs, run)
+		}
+	}
+	if err = errors.Join(errs...); err != nil {
+		return commandFailed(fs.Name(), fmt.Errorf("invalid configuration:\n%v", err))
+	}
+
+	fmt.Printf("sweeping %v combinations x %v seeds = %v runs on %v workers\n",
+		len(combos), *seeds, len(runs), *workers)
+	jobs := make(chan *sweepRun)
+	outcomes := make(chan sweepOutcome)
+	for w := 0; w < *workers; w++ {
+		go func() {
+			for run := range jobs {
+				outcomes <- run.train(*finalEpisodes)
+			}
+		}()
+	}
+	go func() {
+		for _, run := range runs {
+			jobs <- run
+		}
+		close(jobs)
+	}()
+
+	finals := make([][]float64, len(combos))
+	aucs := make([][]float64, len(combos))
+	failed := make([]int, len(combos))
+	for done := 1; done <= len(runs); done++ {
+		out := <-outcomes
+		label := sweepLabel(axes, combos[out.run.combo])
+		if out.err != nil {
+			failed[out.run.combo]++
+			fmt.Fprintf(os.Stderr, "gorl %v: run %v, seed %v failed: %v\n", fs.Name(), label, out.run.seed, out.err)
+			continue
+		}
+		finals[out.run.combo] = append(finals[out.run.combo], out.final)
+		aucs[out.run.combo] = append(aucs[out.run.combo], out.auc)
+		fmt.Printf("[%v/%v] %v, seed %v: final %.4g, auc %.4g\n", done, len(runs), label, out.run.seed, out.final, out.auc)
+	}
+
+	results := make([]SweepResult, len(combos))
+	for ci, combo := range combos {
+		results[ci].Values = make([]string, len(axes))
+		for ai, vi := range combo {
+			results[ci].Values[ai] = axes[ai].Values[vi]
+		}
+		results[ci].Runs, results[ci].Failed = len(finals[ci]), failed[ci]
+		if len(finals[ci]) > 0 {
+			results[ci].Final, results[ci].AUC = metrics.Summarize(finals[ci]), metrics.Summarize(aucs[ci])
+		}
+	}
+	resultsFile := filepath.Join(*outDir, "results.csv")
+	if err = writeSweepResults(resultsFile, axes, results); err != nil {
+		return commandFailed(fs.Name(), err)
+	}
+	printSweepResults(os.Stdout, axes, results)
+	fmt.Printf("results written to %v\n", resultsFile)
+	for ci := range results {
+		if results[ci].Failed > 0 {
+			return exitFailure
+		}
+	}
+	return exitOK
+}
+
+// describe a combination of parameter values
+func sweepLabel(axes []SweepAxis, combo []int) string {
+	parts := make([]string, len(combo))
+	for ai, vi := range combo {
+		parts[ai] = fmt.Sprintf("%v=%v", axes[ai].Name(), axes[ai].Values[vi])
+	}
+	return strings.Join(parts, " ")
+}
+
+var sweepResultHeader = []string{"runs", "failed", "final_mean", "final_std", "final_min", "final_max",
+	"auc_mean", "auc_std", "auc_min", "auc_max"}
+
+// write the results table as comma-separated values, one row per combination
+func writeSweepResults(filename string, axes []SweepAxis, results []SweepResult) (err error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("error writing sweep results: %v", err)
+	}
+	defer closeOnReturn(f, &err)
+	w := csv.NewWriter(f)
+	header := make([]string, 0, len(axes)+len(sweepResultHeader))
+	for i := range axes {
+		header = append(header, axes[i].Name())
+	}
+	w.Write(append(header, sweepResultHeader...))
+	g := func(x float64) string { return strconv.FormatFloat(x, 'g', -1, 64) }
+	for _, res := range results {
+		w.Write(append(append([]string{}, res.Values...),
+			strconv.Itoa(res.Runs), strconv.Itoa(res.Failed),
+			g(res.Final.Mean), g(res.Final.Std), g(res.Final.Min), g(res.Final.Max),
+			g(res.AUC.Mean), g(res.AUC.Std), g(res.AUC.Min), g(res.AUC.Max)))
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// print the results table, best final performance first
+func printSweepResults(out io.Writer, axes []SweepAxis, results []SweepResult) {
+	sorted := append([]SweepResult{}, results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if (sorted[i].Runs > 0) != (sorted[j].Runs > 0) {
+			return sorted[i].Runs > 0
+		}
+		return sorted[i].Final.Mean > sorted[j].Final.Mean
+	})
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	for i := range axes {
+		fmt.Fprintf(w, "%v\t", axes[i].Name())
+	}
+	fmt.Fprintln(w, "runs\tfinal mean\tfinal std\tauc mean\tauc std")
+	for _, res := range sorted {
+		for _, v := range res.Values {
+			fmt.Fprintf(w, "%v\t", v)
+		}
+		if res.Runs == 0 {
+			fmt.Fprintf(w, "0/%v\t-\t-\t-\t-\n", res.Failed)
+			continue
+		}
+		fmt.Fprintf(w, "%v/%v\t%.4g\t%.4g\t%.4g\t%.4g\n", res.Runs, res.Runs+res.Failed,
+			res.Final.Mean, res.Final.Std, res.AUC.Mean, res.AUC.Std)
+	}
+	w.Flush()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/deong/gorl/config"
+)
+
+func TestParseSweepSpec(t *testing.T) {
+	text := `
+[learning]
+alpha = 0.1:0.1:0.5
+lambda = 0, 0.9
+learner = qlearning
+
+[environment]
+state_grid = 3 3 3 3, 5 5 5 5
+action_grid = 3:2:9
+`
+	spec, err := config.Parse(strings.NewReader(text), "sweep.cfg")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing the specification: %v\n", err)
+	}
+	axes, err := ParseSweepSpec(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error reading the sweep: %v\n", err)
+	}
+	expected := map[string][]string{
+		"learning.alpha":          {"0.1", "0.2", "0.3", "0.4", "0.5"},
+		"learning.lambda":         {"0", "0.9"},
+		"learning.learner":        {"qlearning"},
+		"environment.state_grid":  {"3 3 3 3", "5 5 5 5"},
+		"environment.action_grid": {"3", "5", "7", "9"},
+	}
+	if len(axes) != len(expected) {
+		t.Errorf("Expected %v axes, found %v\n", len(expected), len(axes))
+	}
+	for _, ax := range axes {
+		if !reflect.DeepEqual(ax.Values, expected[ax.Name()]) {
+			t.Errorf("Values of %v are %q, expected %q\n", ax.Name(), ax.Values, expected[ax.Name()])
+		}
+	}
+	if n := len(sweepCombinations(axes)); n != 5*2*1*2*4 {
+		t.Errorf("Expected %v combinations, found %v\n", 5*2*2*4, n)
+	}
+
+	// every problem is reported at once
+	bad := `
+[learning]
+alpha = 0.5:-0.1:0.9
+seed = 1 2 3
+alhpa = 0.1
+`
+	spec, _ = config.Parse(strings.NewReader(bad), "bad.cfg")
+	if _, err = ParseSweepSpec(spec); err == nil {
+		t.Fatal("Expected an error reading a bad sweep specification.\n")
+	}
+	for _, name := range []string{"[learning] alpha", "[learning] seed", "[learning] alhpa"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Sweep error does not mention %v: %v\n", name, err)
+		}
+	}
+}
+
+func TestSweepCombinations(t *testing.T) {
+	axes := []SweepAxis{{"a", "x", []string{"1", "2"}}, {"a", "y", []string{"p", "q", "r"}}}
+	expected := [][]int{{0, 0}, {0, 1}, {0, 2}, {1, 0}, {1, 1}, {1, 2}}
+	if combos := sweepCombinations(axes); !reflect.DeepEqual(combos, expected) {
+		t.Errorf("Combinations are %v, expected %v\n", combos, expected)
+	}
+}
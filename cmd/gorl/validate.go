@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/learn"
+	"github.com/deong/gorl/space"
+)
+
+// Validate a configuration as config.Validate does, and also check that the
+// problem and learner it names exist and that state_grid has one entry per
+// feature of the problem, reporting every error at once.
+func validateConfig(cfg *config.Config) error {
+	var errs []error
+	if err := config.Validate(cfg); err != nil {
+		errs = append(errs, err)
+	}
+	if name, err := cfg.String("environment", "problem"); err != nil {
+		errs = append(errs, err)
+	} else if env, ok := environment.New(name, nil); !ok {
+		errs = append(errs, &config.ParamError{Section: "environment", Key: "problem",
+			Err: fmt.Errorf("unknown problem '%v' (expected one of %v)", name, environment.Names())})
+	} else if grid, err := cfg.IntArray("environment", "state_grid"); err == nil {
+		if nf := len(env.Features()); len(grid) != nf {
+			errs = append(errs, &config.ParamError{Section: "environment", Key: "state_grid",
+				Err: fmt.Errorf("%v entries given but problem '%v' has %v features", len(grid), name, nf)})
+		}
+	}
+	if name, err := cfg.String("learning", "learner"); err != nil {
+		errs = append(errs, err)
+	} else if _, ok := learn.New(name, nil); !ok {
+		errs = append(errs, &config.ParamError{Section: "learning", Key: "learner",
+			Err: fmt.Errorf("unknown learner '%v' (expected one of %v)", name, learn.Names())})
+	}
+	return errors.Join(errs...)
+}
+
+// record the default state_grid of the configured problem in a validated
+// configuration that does not set one, so that the effective configuration
+// shows it
+func recordStateGrid(cfg *config.Config) {
+	if cfg.Has("environment", "state_grid") {
+		return
+	}
+	name, _ := cfg.String("environment", "problem")
+	if env, ok := environment.New(name, nil); ok {
+		cfg.Set("environment", "state_grid", space.DefaultStateGrid(len(env.Features())))
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deong/gorl/config"
+)
+
+func TestValidateConfig(t *testing.T) {
+	text := `[environment]
+problem = pendulum
+action_grid = 3
+
+[learning]
+learner = qlearnin
+alpha = 1.5
+`
+	cfg, err := config.Parse(strings.NewReader(text), "bad.cfg")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing configuration: %v\n", err)
+	}
+	err = validateConfig(cfg)
+	if err == nil {
+		t.Fatal("Expected an error validating the configuration.\n")
+	}
+	// problems found by config.Validate are reported along with unknown names
+	for _, name := range []string{"[environment] problem", "[learning] learner", "[learning] alpha"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Validation error does not mention %v: %v\n", name, err)
+		}
+	}
+
+	// state_grid must match the number of features of the problem
+	cfg = config.New()
+	cfg.Set("environment", "problem", "mountain_car")
+	cfg.Set("environment", "state_grid", "5 5 5")
+	if err = validateConfig(cfg); err == nil || !strings.Contains(err.Error(), "[environment] state_grid") {
+		t.Errorf("Expected an error naming state_grid, got %v\n", err)
+	}
+
+	// without a state_grid, the default for the problem is recorded
+	cfg = config.New()
+	cfg.Set("environment", "problem", "mountain_car")
+	if err = validateConfig(cfg); err != nil {
+		t.Fatalf("Unexpected error validating the defaults: %v\n", err)
+	}
+	recordStateGrid(cfg)
+	if grid, err := cfg.IntArray("environment", "state_grid"); err != nil || len(grid) != 2 {
+		t.Errorf("Expected a default state_grid with 2 entries, got %v (%v)\n", grid, err)
+	}
+}
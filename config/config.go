@@ -0,0 +1,319 @@
+// Package config defines a convenient interface for configuration
+// information.
+//
+// Hides the details of parsing out the parameters and provides additional
+// functionality (e.g., parsing an array of int or float64 values). Every
+// error names the section and key it concerns, so that a bad configuration
+// file can be fixed without reading the code.
+//
+// Configuration files are INI-style, in the format goconf reads: "[section]"
+// headers followed by "key = value" (or "key: value") lines. Lines starting
+// with '#' or ';' are comments; a '#' or ';' later in a line is part of the
+// value. A value may be continued on the lines that follow it if they start
+// with a space or tab, the pieces being joined by single spaces, and a value
+// in double quotes has them removed, keeping any spaces inside. Section and
+// key names are case-insensitive, and a key may be set only once in a
+// section. Keys appearing before the first section header belong to the
+// "default" section, and are used for any section that does not set them
+// itself. Parameters that are not set at all take the built-in defaults
+// listed in params.go.
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the section holding keys that appear before any section header
+const defaultSection = "default"
+
+// A Config holds the parameters of a run, organized into sections
+type Config struct {
+	sections map[string]map[string]string
+}
+
+// An error concerning a single configuration parameter
+type ParamError struct {
+	Section string
+	Key     string
+	Err     error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("[%v] %v: %v", e.Section, e.Key, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// the error wrapped in a ParamError for a parameter that is not set
+var ErrMissing = errors.New("parameter is not set")
+
+// return an empty configuration
+func New() *Config {
+	return &Config{map[string]map[string]string{}}
+}
+
+// parse the named configuration file, returning any error encountered
+func Load(filename string) (*Config, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f, filename)
+}
+
+// parse a configuration from r; name is used in error messages
+func Parse(r io.Reader, name string) (*Config, error) {
+	cfg := New()
+	section, key, value := defaultSection, "", ""
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			key = ""
+			continue
+		}
+		if raw[0] == ' ' || raw[0] == '\t' {
+			if key == "" {
+				return nil, fmt.Errorf("%v:%v: continuation line '%v' does not follow a value", name, lineNo, line)
+			}
+			value = strings.TrimSpace(value + " " + line)
+			cfg.Set(section, key, unquote(value))
+			continue
+		}
+		key = ""
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' || len(line) < 3 {
+				return nil, fmt.Errorf("%v:%v: malformed section header '%v'", name, lineNo, line)
+			}
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			if _, ok := cfg.sections[section]; !ok {
+				cfg.sections[section] = map[string]string{}
+			}
+			continue
+		}
+		i := strings.IndexAny(line, "=:")
+		if i <= 0 {
+			return nil, fmt.Errorf("%v:%v: expected 'key = value', found '%v'", name, lineNo, line)
+		}
+		key = strings.ToLower(strings.TrimSpace(line[:i]))
+		if _, dup := cfg.sections[section][key]; dup {
+			return nil, fmt.Errorf("%v:%v: [%v] %v is set more than once", name, lineNo, section, key)
+		}
+		value = strings.TrimSpace(line[i+1:])
+		cfg.Set(section, key, unquote(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+	return cfg, nil
+}
+
+// remove the double quotes around a value, if it has them
+func unquote(val string) string {
+	if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+		return val[1 : len(val)-1]
+	}
+	return val
+}
+
+// set the value of a parameter, creating its section if need be
+func (cfg *Config) Set(sec, name, val string) {
+	sec, name = strings.ToLower(sec), strings.ToLower(name)
+	if cfg.sections[sec] == nil {
+		cfg.sections[sec] = map[string]string{}
+	}
+	cfg.sections[sec][name] = val
+}
+
+// return a copy of the configuration that can be changed independently
+func (cfg *Config) Clone() *Config {
+	c := New()
+	for sec, keys := range cfg.sections {
+		c.sections[sec] = make(map[string]string, len(keys))
+		for key, val := range keys {
+			c.sections[sec][key] = val
+		}
+	}
+	return c
+}
+
+// check whether a parameter is set, either in its section or the default
+// section, rather than taking its built-in default
+func (cfg *Config) Has(sec, name string) bool {
+	sec, name = strings.ToLower(sec), strings.ToLower(name)
+	if _, ok := cfg.sections[sec][name]; ok {
+		return true
+	}
+	_, ok := cfg.sections[defaultSection][name]
+	return ok
+}
+
+// apply an override of the form "section.key=value"
+func (cfg *Config) Override(setting string) error {
+	eq := strings.Index(setting, "=")
+	if eq < 0 {
+		return fmt.Errorf("override '%v' is not of the form section.key=value", setting)
+	}
+	name := strings.TrimSpace(setting[:eq])
+	dot := strings.Index(name, ".")
+	if dot <= 0 || dot == len(name)-1 {
+		return fmt.Errorf("override '%v' is not of the form section.key=value", setting)
+	}
+	cfg.Set(strings.TrimSpace(name[:dot]), strings.TrimSpace(name[dot+1:]), strings.TrimSpace(setting[eq+1:]))
+	return nil
+}
+
+// return the names of the sections, in sorted order
+func (cfg *Config) Sections() []string {
+	secs := make([]string, 0, len(cfg.sections))
+	for sec := range cfg.sections {
+		secs = append(secs, sec)
+	}
+	sort.Strings(secs)
+	return secs
+}
+
+// return the names of the keys set in a section, in sorted order
+func (cfg *Config) Keys(sec string) []string {
+	sec = strings.ToLower(sec)
+	keys := make([]string, 0, len(cfg.sections[sec]))
+	for key := range cfg.sections[sec] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// look up the unparsed value of a parameter
+func (cfg *Config) raw(sec, name string) (string, error) {
+	sec, name = strings.ToLower(sec), strings.ToLower(name)
+	if val, ok := cfg.sections[sec][name]; ok {
+		return val, nil
+	}
+	if val, ok := cfg.sections[defaultSection][name]; ok {
+		return val, nil
+	}
+	if val, ok := parameterDefault(sec, name); ok {
+		return val, nil
+	}
+	return "", &ParamError{sec, name, ErrMissing}
+}
+
+// return the value of a given parameter as a string
+func (cfg *Config) String(sec, name string) (val string, err error) {
+	val, err = cfg.raw(sec, name)
+	return
+}
+
+// return the value of the parameter as an int
+func (cfg *Config) Int(sec, name string) (val int, err error) {
+	var s string
+	if s, err = cfg.raw(sec, name); err != nil {
+		return
+	}
+	if val, err = strconv.Atoi(s); err != nil {
+		err = &ParamError{sec, name, fmt.Errorf("'%v' is not an integer", s)}
+	}
+	return
+}
+
+// return the value of the parameter as a non-negative int
+func (cfg *Config) Uint(sec, name string) (val uint, err error) {
+	var s string
+	if s, err = cfg.raw(sec, name); err != nil {
+		return
+	}
+	var uval uint64
+	if uval, err = strconv.ParseUint(s, 10, 0); err != nil {
+		return 0, &ParamError{sec, name, fmt.Errorf("'%v' is not a non-negative integer", s)}
+	}
+	val = uint(uval)
+	return
+}
+
+// return the value of the parameter as a float64
+func (cfg *Config) Float64(sec, name string) (val float64, err error) {
+	var s string
+	if s, err = cfg.raw(sec, name); err != nil {
+		return
+	}
+	if val, err = strconv.ParseFloat(s, 64); err != nil {
+		err = &ParamError{sec, name, fmt.Errorf("'%v' is not a number", s)}
+	}
+	return
+}
+
+// return the value of the parameter as a slice of ints
+func (cfg *Config) IntArray(sec, name string) (val []int, err error) {
+	var s string
+	if s, err = cfg.raw(sec, name); err != nil {
+		return []int{}, err
+	}
+	if val, err = parseIntVector(s); err != nil {
+		return []int{}, &ParamError{sec, name, err}
+	}
+	return
+}
+
+// return the value of the parameter as a slice of float64s
+func (cfg *Config) Float64Array(sec, name string) (val []float64, err error) {
+	var s string
+	if s, err = cfg.raw(sec, name); err != nil {
+		return []float64{}, err
+	}
+	if val, err = parseFloat64Vector(s); err != nil {
+		return []float64{}, &ParamError{sec, name, err}
+	}
+	return
+}
+
+// parse a string of numbers separated by whitespace into a slice of float64s
+func parseFloat64Vector(str string) ([]float64, error) {
+	tokens := strings.Fields(str)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("expected a list of numbers")
+	}
+	vals := make([]float64, len(tokens))
+	for i := range tokens {
+		val, err := strconv.ParseFloat(tokens[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("element %v ('%v') is not a number", i+1, tokens[i])
+		}
+		vals[i] = val
+	}
+	return vals, nil
+}
+
+// parse a string of numbers separated by whitespace into a slice of ints
+func parseIntVector(str string) ([]int, error) {
+	tokens := strings.Fields(str)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("expected a list of integers")
+	}
+	vals := make([]int, len(tokens))
+	for i := range tokens {
+		val, err := strconv.Atoi(tokens[i])
+		if err != nil {
+			return nil, fmt.Errorf("element %v ('%v') is not an integer", i+1, tokens[i])
+		}
+		vals[i] = val
+	}
+	return vals, nil
+}
+
+// check whether an error reports that a parameter is not set, which is not a
+// problem for optional parameters
+func IsMissing(err error) bool {
+	return errors.Is(err, ErrMissing)
+}
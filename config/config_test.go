@@ -0,0 +1,214 @@
+package config
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	// check an existing file
+	if cfg, err := Load("sample_test.cfg"); err != nil {
+		t.Errorf("Error opening configuration file: %v\n", "sample.cfg")
+	} else {
+		// check a few parameters
+		if lm, err2 := cfg.Float64("learning", "lambda"); err2 != nil {
+			t.Error("Error reading parameter lambda.\n")
+		} else if !epsilonEqual(lm, 0.9, 0.00001) {
+			t.Errorf("Incorrect value of parameter lambda (%v) found: expected 0.9.\n", lm)
+		}
+
+		// check an int parameter
+		if eps, err3 := cfg.Int("learning", "epochs"); err3 != nil {
+			t.Error("Error reading parameter epochs.\n")
+		} else if eps != 200 {
+			t.Errorf("Incorrect value of parameter epochs (%v) found: expected 200.\n", eps)
+		}
+
+		// test the string retrieval
+		if ln, err4 := cfg.String("learning", "learner"); err4 != nil {
+			t.Error("Error reading parameter learner.\n")
+		} else if ln != "qlearning" {
+			t.Errorf("Incorrect value of parameter learner (%v) found: expected 'qlearning'.\n", ln)
+		}
+
+		// check a parameter that's misspelled
+		if _, err4 := cfg.String("learning", "learnner"); err4 == nil {
+			t.Error("Expected to get an error reading parameter learnner.\n")
+		} else if !IsMissing(err4) || !strings.Contains(err4.Error(), "[learning] learnner") {
+			t.Errorf("Error reading parameter learnner does not name it: %v\n", err4)
+		}
+
+		// try an []int parameter
+		if grid, err5 := cfg.IntArray("environment", "state_grid"); err5 != nil {
+			t.Error("Error reading parameter state_grid.\n")
+		} else {
+			right := []int{5, 5, 5, 5}
+			for i := range grid {
+				if grid[i] != right[i] {
+					t.Errorf("Incorrect value of parameter state_grid (%v) found: expected %v.\n",
+						grid, right)
+				}
+			}
+		}
+
+		// the sample configuration is valid
+		if err6 := Validate(cfg); err6 != nil {
+			t.Errorf("Unexpected error validating sample configuration: %v\n", err6)
+		}
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	bad := []string{
+		"[environment\nproblem = cart_pole\n",
+		"[environment]\nproblem cart_pole\n",
+		"[learning]\nalpha = 0.1\nalpha = 0.2\n",
+		"[learning]\nAlpha = 0.1\n[environment]\n[LEARNING]\nalpha = 0.2\n",
+	}
+	for _, text := range bad {
+		if _, err := Parse(strings.NewReader(text), "bad.cfg"); err == nil {
+			t.Errorf("Expected an error parsing %q.\n", text)
+		} else if !strings.HasPrefix(err.Error(), "bad.cfg:") {
+			t.Errorf("Parse error does not give the file and line: %v\n", err)
+		}
+	}
+}
+
+func TestParseConfigSyntax(t *testing.T) {
+	text := `; a comment
+# another comment
+alpha = 0.5
+
+[Environment]
+State_Grid = 10 10
+	10
+  10
+action_grid: 5
+
+[metrics]
+file = "my metrics.csv"
+format = "csv
+log_interval = 10 # not a comment
+`
+	cfg, err := Parse(strings.NewReader(text), "syntax.cfg")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing configuration: %v\n", err)
+	}
+	tests := []struct {
+		sec, key, expected string
+	}{
+		// keys before the first section are defaults for every section
+		{"learning", "alpha", "0.5"},
+		// names are case-insensitive, and continuation lines join the value
+		{"environment", "state_grid", "10 10 10 10"},
+		{"environment", "action_grid", "5"},
+		// only a value wholly in quotes loses them
+		{"metrics", "file", "my metrics.csv"},
+		{"metrics", "format", "\"csv"},
+		{"metrics", "log_interval", "10 # not a comment"},
+	}
+	for _, tt := range tests {
+		if val, err := cfg.String(tt.sec, tt.key); err != nil || val != tt.expected {
+			t.Errorf("[%v] %v = %q (%v), expected %q\n", tt.sec, tt.key, val, err, tt.expected)
+		}
+	}
+
+	// a continuation line needs a value to continue, and a comment or blank
+	// line ends the value
+	for _, text := range []string{"  alpha = 0.1\n", "[learning]\n\tepochs = 5\n",
+		"[learning]\nalpha = 0.1\n# comment\n  0.2\n"} {
+		if _, err := Parse(strings.NewReader(text), "bad.cfg"); err == nil || !strings.Contains(err.Error(), "continuation") {
+			t.Errorf("Expected a continuation error parsing %q, got %v\n", text, err)
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	text := `[environment]
+problem = pendulum
+state_grid = 5 x 5
+action_grid = 3
+
+[learning]
+learner = qlearning
+alpha = 1.5
+learnner = qlearning
+trace = dutsch
+`
+	cfg, err := Parse(strings.NewReader(text), "bad.cfg")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing configuration: %v\n", err)
+	}
+	err = Validate(cfg)
+	if err == nil {
+		t.Fatal("Expected an error validating the configuration.\n")
+	}
+	// every problem is reported, each naming its section and key
+	for _, name := range []string{"[environment] state_grid",
+		"[learning] alpha", "[learning] learnner", "[learning] trace"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Validation error does not mention %v: %v\n", name, err)
+		}
+	}
+	var pe *ParamError
+	if !errors.As(err, &pe) {
+		t.Errorf("Validation error does not wrap a ParamError: %v\n", err)
+	}
+}
+
+func TestDefaultsAndOverrides(t *testing.T) {
+	cfg := New()
+	// every parameter with a default can be read from an empty configuration
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Unexpected error validating the defaults: %v\n", err)
+	}
+	if _, err := cfg.Int("learning", "seed"); !IsMissing(err) {
+		t.Errorf("Expected seed to have no default, got %v\n", err)
+	}
+	if cfg.Has("learning", "alpha") {
+		t.Error("A defaulted parameter should not count as set.\n")
+	}
+
+	// overrides replace both defaults and values from a file
+	if err := cfg.Override("learning.alpha = 0.3"); err != nil {
+		t.Fatalf("Unexpected error applying an override: %v\n", err)
+	}
+	if alpha, err := cfg.Float64("learning", "alpha"); err != nil || !epsilonEqual(alpha, 0.3, 1e-12) {
+		t.Errorf("Expected alpha of 0.3 after override, got %v (%v)\n", alpha, err)
+	}
+	cfg.Override("environment.state_grid=3 3")
+	if grid, err := cfg.IntArray("environment", "state_grid"); err != nil || len(grid) != 2 {
+		t.Errorf("Expected an overridden state_grid of 2 entries, got %v (%v)\n", grid, err)
+	}
+	for _, bad := range []string{"alpha=0.3", "learning.alpha", ".alpha=1", "learning.=1"} {
+		if err := cfg.Override(bad); err == nil {
+			t.Errorf("Expected an error for override '%v'\n", bad)
+		}
+	}
+
+	// the effective configuration reads back to the same values
+	var b strings.Builder
+	if err := WriteEffective(&b, cfg); err != nil {
+		t.Fatalf("Unexpected error writing the configuration: %v\n", err)
+	}
+	cfg2, err := Parse(strings.NewReader(b.String()), "effective")
+	if err != nil {
+		t.Fatalf("Unexpected error reading the effective configuration: %v\n", err)
+	}
+	for _, p := range Parameters {
+		v1, err1 := cfg.String(p.Section, p.Key)
+		v2, err2 := cfg2.String(p.Section, p.Key)
+		if v1 != v2 || (err1 == nil) != (err2 == nil) {
+			t.Errorf("[%v] %v: %q before writing, %q after\n", p.Section, p.Key, v1, v2)
+		}
+	}
+}
+
+func epsilonEqual(x, y, epsilon float64) bool {
+	if math.Abs(x-y) < epsilon {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,255 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// The kinds of value a parameter can take
+type Kind int
+
+const (
+	StringParam Kind = iota
+	IntParam
+	UintParam
+	FloatParam
+	IntListParam
+)
+
+// A parameter that may appear in a configuration file. Numeric parameters
+// with Bounds must lie within them. Parameters with a Default take that value
+// when they are not set; those without one are optional, are derived from
+// other parameters (like state_grid), or are chosen at run time (like seed).
+type Parameter struct {
+	Section string
+	Key     string
+	Kind    Kind
+	Bounds  *Bounds
+	Default string
+	Doc     string
+}
+
+// The closed interval in which a numeric parameter must lie
+type Bounds struct {
+	Min float64
+	Max float64
+}
+
+var (
+	unitInterval = &Bounds{0.0, 1.0}
+	nonNegative  = &Bounds{0.0, math.Inf(1)}
+)
+
+// Every parameter understood by gorl. A configuration file setting any other
+// key is rejected, so that misspelled names are caught before a run starts.
+var Parameters = []Parameter{
+	{"environment", "problem", StringParam, nil, "cart_pole", "the problem to solve"},
+	{"environment", "state_grid", IntListParam, nil, "", "lattice points along each state feature (default: 10 per feature)"},
+	{"environment", "action_grid", UintParam, nil, "5", "number of evenly spaced actions"},
+	{"environment", "max_steps", UintParam, nil, "10000", "step limit per episode; 0 for none during training"},
+
+	{"features", "basis", StringParam, nil, "tiles", "features of the linear learners: tiles, rbf, or fourier"},
+	{"features", "tilings", UintParam, nil, "8", "number of offset tilings in tile coding"},
+	{"features", "tiles", UintParam, nil, "8", "tiles across the range of each state feature in each tiling"},
+	{"features", "hash_size", UintParam, nil, "0", "number of features the tiles are hashed into; 0 for no hashing"},
+	{"features", "width", FloatParam, nonNegative, "1", "standard deviation of radial basis functions, in spacings of the state_grid lattice"},
+	{"features", "order", UintParam, nil, "3", "highest frequency along each state feature in the Fourier basis"},
+
+	{"learning", "learner", StringParam, nil, "qlearning", "the learning algorithm"},
+	{"learning", "epochs", UintParam, nil, "200", "number of training episodes"},
+	{"learning", "alpha", FloatParam, unitInterval, "0.1", "step size"},
+	{"learning", "beta", FloatParam, unitInterval, "0.01", "step size of the average reward estimate"},
+	{"learning", "reward_window", UintParam, nil, "1000", "steps over which rlearning reports its average reward; 0 disables the reports"},
+	{"learning", "gamma", FloatParam, unitInterval, "0.99", "discount factor"},
+	{"learning", "lambda", FloatParam, unitInterval, "0.9", "eligibility trace decay"},
+	{"learning", "epsilon", FloatParam, unitInterval, "0.1", "exploration rate"},
+	{"learning", "trace", StringParam, nil, "accumulating", "eligibility trace: accumulating, replacing, or dutch"},
+	{"learning", "trace_threshold", FloatParam, unitInterval, "0.0001", "trace entries that decay below this are dropped; 0 keeps them until they vanish"},
+	{"learning", "q_lambda", StringParam, nil, "watkins", "Q(lambda) variant: watkins, cutting the trace after exploratory actions, or peng"},
+	{"learning", "visits", StringParam, nil, "first", "returns used by Monte Carlo learners: first or every visit to a pair"},
+	{"learning", "planning_steps", UintParam, nil, "10", "simulated updates made from the learned model after each real step"},
+	{"learning", "priority_threshold", FloatParam, nonNegative, "0.0001", "smallest TD error for which prioritized sweeping queues a pair"},
+	{"learning", "actor_alpha", FloatParam, unitInterval, "0.1", "step size of the actor's preferences in actor-critic and reinforce, and of cacla's actor"},
+	{"learning", "critic_alpha", FloatParam, unitInterval, "0.1", "step size of the critic's state values in actor-critic and cacla, and of the reinforce baseline"},
+	{"learning", "temperature", FloatParam, nonNegative, "1", "temperature of softmax policies; higher is more random"},
+	{"learning", "follow", StringParam, nil, "mode", "how gorl run follows a stochastic policy: mode or sample"},
+	{"learning", "sigma", FloatParam, nonNegative, "0.1", "standard deviation of the Gaussian exploration noise added to cacla's actions"},
+	{"learning", "replay_size", UintParam, nil, "10000", "transitions kept in the replay buffer of dqn"},
+	{"learning", "batch_size", UintParam, nil, "32", "transitions drawn from the replay buffer for each update of dqn"},
+	{"learning", "replay_start", UintParam, nil, "1000", "transitions collected before dqn starts learning"},
+	{"learning", "target_interval", UintParam, nil, "500", "steps between copies of dqn's network to its target network"},
+	{"learning", "seed", IntParam, nil, "", "random seed of the run (default: taken from the clock)"},
+	{"learning", "checkpoint_interval", UintParam, nil, "0", "epochs between checkpoints; 0 disables them"},
+	{"learning", "checkpoint_file", StringParam, nil, "checkpoint.json", "file to which checkpoints are written"},
+
+	{"network", "hidden", IntListParam, nil, "64 64", "units in each hidden layer of neural networks"},
+	{"network", "activation", StringParam, nil, "relu", "activation of hidden layers: relu or tanh"},
+	{"network", "learning_rate", FloatParam, nonNegative, "0.001", "step size of the Adam optimizer"},
+
+	{"metrics", "format", StringParam, nil, "none", "per-episode metrics format: csv, jsonl, or none"},
+	{"metrics", "file", StringParam, nil, "", "file to which metrics are written; reward windows go to its _windows companion"},
+	{"metrics", "log_interval", UintParam, nil, "1", "epochs between progress lines on standard output; 0 disables them"},
+
+	{"evaluation", "interval", UintParam, nil, "0", "epochs between evaluations during training; 0 disables them"},
+	{"evaluation", "episodes", UintParam, nil, "10", "number of evaluation episodes"},
+	{"evaluation", "epsilon", FloatParam, unitInterval, "0", "exploration rate during evaluation"},
+	{"evaluation", "max_steps", UintParam, nil, "", "step limit per evaluation episode (default: [environment] max_steps)"},
+	{"evaluation", "seed", IntParam, nil, "", "seed from which evaluation episode seeds are derived (default: derived from the run's seed)"},
+	{"evaluation", "file", StringParam, nil, "", "file to which periodic evaluations are written"},
+}
+
+// look up a parameter by section and key, returning nil if it is unknown
+func Find(sec, key string) *Parameter {
+	for i := range Parameters {
+		if Parameters[i].Section == sec && Parameters[i].Key == key {
+			return &Parameters[i]
+		}
+	}
+	return nil
+}
+
+// return the built-in value of a parameter that is not set, if it has one
+func parameterDefault(sec, key string) (string, bool) {
+	if p := Find(sec, key); p != nil && p.Default != "" {
+		return p.Default, true
+	}
+	return "", false
+}
+
+// Write the effective configuration: every parameter that is set or has a
+// default, with its resolved value, in the format read by Load.
+func WriteEffective(w io.Writer, cfg *Config) error {
+	section := ""
+	for i := range Parameters {
+		p := &Parameters[i]
+		val, err := cfg.String(p.Section, p.Key)
+		if err != nil {
+			continue
+		}
+		if p.Section != section {
+			if section != "" {
+				if _, err = fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			section = p.Section
+			if _, err = fmt.Fprintf(w, "[%v]\n", section); err != nil {
+				return err
+			}
+		}
+		if _, err = fmt.Fprintf(w, "%v = %v\n", p.Key, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check that a parameter's value can be parsed and lies within its bounds
+func (p *Parameter) check(cfg *Config) (err error) {
+	var x float64
+	switch p.Kind {
+	case IntParam:
+		var i int
+		i, err = cfg.Int(p.Section, p.Key)
+		x = float64(i)
+	case UintParam:
+		var u uint
+		u, err = cfg.Uint(p.Section, p.Key)
+		x = float64(u)
+	case FloatParam:
+		x, err = cfg.Float64(p.Section, p.Key)
+	case IntListParam:
+		_, err = cfg.IntArray(p.Section, p.Key)
+	default:
+		_, err = cfg.String(p.Section, p.Key)
+	}
+	if err == nil && p.Bounds != nil && (x < p.Bounds.Min || x > p.Bounds.Max) {
+		err = &ParamError{p.Section, p.Key, fmt.Errorf("%v is outside of [%v, %v]", x, p.Bounds.Min, p.Bounds.Max)}
+	}
+	return
+}
+
+// Validate the whole configuration before anything is built from it,
+// reporting every unknown key, malformed value, and out-of-range value at
+// once. Checks that depend on the problem or learner chosen, such as whether
+// they exist, are left to the packages that build them.
+func Validate(cfg *Config) error {
+	var errs []error
+	for _, sec := range cfg.Sections() {
+		for _, key := range cfg.Keys(sec) {
+			if sec == defaultSection {
+				if !knownKey(key) {
+					errs = append(errs, &ParamError{sec, key, errors.New("unknown parameter")})
+				}
+				continue
+			}
+			if Find(sec, key) == nil {
+				errs = append(errs, &ParamError{sec, key, errors.New("unknown parameter")})
+			}
+		}
+	}
+	for i := range Parameters {
+		if !cfg.Has(Parameters[i].Section, Parameters[i].Key) {
+			continue
+		}
+		if err := Parameters[i].check(cfg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, c := range choices {
+		if val, err := cfg.String(c.section, c.key); err == nil && !contains(c.values, val) {
+			errs = append(errs, &ParamError{c.section, c.key,
+				fmt.Errorf("unknown %v '%v' (expected %v)", c.what, val, oneOf(c.values))})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// The string parameters that must take one of a fixed set of values
+var choices = []struct {
+	section, key, what string
+	values             []string
+}{
+	{"metrics", "format", "format", []string{"csv", "jsonl", "none"}},
+	{"features", "basis", "basis", []string{"tiles", "rbf", "fourier"}},
+	{"network", "activation", "activation", []string{"relu", "tanh"}},
+	{"learning", "trace", "trace", []string{"accumulating", "replacing", "dutch"}},
+	{"learning", "q_lambda", "Q(lambda) variant", []string{"watkins", "peng"}},
+	{"learning", "visits", "visits", []string{"first", "every"}},
+	{"learning", "follow", "follow", []string{"mode", "sample"}},
+}
+
+// list the alternatives as "a", "a or b", or "a, b, or c"
+func oneOf(values []string) string {
+	n := len(values)
+	if n == 1 {
+		return values[0]
+	}
+	if n == 2 {
+		return values[0] + " or " + values[1]
+	}
+	return strings.Join(values[:n-1], ", ") + ", or " + values[n-1]
+}
+
+// check whether a list of strings contains s
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// check whether a key is known in any section
+func knownKey(key string) bool {
+	for i := range Parameters {
+		if Parameters[i].Key == key {
+			return true
+		}
+	}
+	return false
+}
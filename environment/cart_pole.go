@@ -1,12 +1,20 @@
-package main
+package environment
 
 import (
 	"math"
-	"math/rand"
+
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
 )
 
 type CartPoleEnv struct {
 	steps uint
+	rng   *random.Rand
+}
+
+// return a cart pole whose start states are perturbed using rng
+func NewCartPoleEnv(rng *random.Rand) *CartPoleEnv {
+	return &CartPoleEnv{rng: rng}
 }
 
 const (
@@ -21,24 +29,24 @@ const (
 	kMaxSteps       = 1000
 )
 
-var cpFeatureRanges = []Range{
-	Range{-4.5, 4.5},
-	Range{-3.0, 3.0},
-	Range{-math.Pi / 4.0, math.Pi / 4.0},
-	Range{-math.Pi / 4.0, math.Pi / 4.0},
+var cpFeatureRanges = []space.Range{
+	space.Range{Min: -4.5, Max: 4.5},
+	space.Range{Min: -3.0, Max: 3.0},
+	space.Range{Min: -math.Pi / 4.0, Max: math.Pi / 4.0},
+	space.Range{Min: -math.Pi / 4.0, Max: math.Pi / 4.0},
 }
 
-func (env *CartPoleEnv) Features() (f []Range) {
+func (env *CartPoleEnv) Features() (f []space.Range) {
 	f = cpFeatureRanges
 	return
 }
 
-func (env *CartPoleEnv) ActionRange() Range {
-	return Range{-1.0, 1.0}
+func (env *CartPoleEnv) ActionRange() space.Range {
+	return space.Range{Min: -1.0, Max: 1.0}
 }
 
 // return the immediate reward and new state resulting from taking action a in state s
-func (env *CartPoleEnv) ApplyAction(s State, a Action) (newState State, reward float64) {
+func (env *CartPoleEnv) ApplyAction(s space.State, a space.Action) (newState space.State, reward float64) {
 	force := a.Val * kForceMag
 	cosTheta := math.Cos(s.Vals[2])
 	sinTheta := math.Sin(s.Vals[2])
@@ -48,7 +56,7 @@ func (env *CartPoleEnv) ApplyAction(s State, a Action) (newState State, reward f
 	deltaX := temp - kPoleMassLength*deltaTheta*cosTheta/kTotalMass
 
 	// compute the next state
-	newState = MakeState(4)
+	newState = space.MakeState(4)
 	newState.Vals[0] = s.Vals[0] + kTau*s.Vals[1]
 	newState.Vals[1] = s.Vals[1] + kTau*deltaX
 	newState.Vals[2] = s.Vals[2] + kTau*s.Vals[3]
@@ -68,16 +76,18 @@ func (env *CartPoleEnv) ApplyAction(s State, a Action) (newState State, reward f
 	return
 }
 
-// check if we're at a goal state
-func (env *CartPoleEnv) AtGoalState(s State) bool {
-	if env.steps >= kMaxSteps {
-		return true
-	}
-	return false
+// check if we're at a goal state: the pole has been balanced for long enough
+func (env *CartPoleEnv) AtGoalState(s space.State) bool {
+	return env.OutOfTime()
+}
+
+// check if the episode has run out of time, which is the goal of the problem
+func (env *CartPoleEnv) OutOfTime() bool {
+	return env.steps >= kMaxSteps
 }
 
 // check if we're at the fail state
-func (env *CartPoleEnv) AtFailState(s State) bool {
+func (env *CartPoleEnv) AtFailState(s space.State) bool {
 	if math.Abs(s.Vals[0]) > 4.0 || math.Abs(s.Vals[2]) > math.Pi/4.0 {
 		return true
 	}
@@ -85,15 +95,20 @@ func (env *CartPoleEnv) AtFailState(s State) bool {
 }
 
 // return the start state
-func (env *CartPoleEnv) StartState() (s State) {
-	s = State{0, []float64{0.0, 0.0, 0.0, 0.0}}
+func (env *CartPoleEnv) StartState() (s space.State) {
+	s = space.State{Id: 0, Vals: []float64{0.0, 0.0, 0.0, 0.0}}
 	// randomly perturb it slightly by applying a small random action 
-	r := rand.NormFloat64() * 0.25
-	a := Action{0, r, false}
+	r := env.rng.NormFloat64() * 0.25
+	a := space.Action{Id: 0, Val: r}
 	s, _ = env.ApplyAction(s, a)
 	return
 }
 
+// return the generator used to perturb the start state
+func (env *CartPoleEnv) Rng() *random.Rand {
+	return env.rng
+}
+
 // reset the count
 func (env *CartPoleEnv) Reset() {
 	env.steps = 0
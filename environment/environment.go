@@ -0,0 +1,98 @@
+// Package environment defines the interface to reinforcement learning
+// problems, along with the cart pole and mountain car problems.
+package environment
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// Defines an interface for all reinforcement learning problems
+type Environment interface {
+	Features() []space.Range
+	ActionRange() space.Range
+	ApplyAction(s space.State, a space.Action) (sp space.State, reward float64)
+	AtGoalState(s space.State) bool
+	AtFailState(s space.State) bool
+	StartState() space.State
+	Reset()
+}
+
+// Environments with random dynamics implement Stochastic so that the state of
+// their generator can be checkpointed along with the learner.
+type Stochastic interface {
+	Rng() *random.Rand
+}
+
+// Environments whose goal is to last for a number of steps, rather than to
+// reach some set of states, implement TimeLimited. Running out of time cuts an
+// episode short without its last state being terminal, as rewards would have
+// kept coming had the episode been allowed to go on.
+type TimeLimited interface {
+	OutOfTime() bool
+}
+
+// check whether s is a terminal state of env, from which no further reward
+// can follow: a failure, or a goal other than running out of time
+func Terminal(env Environment, s space.State) bool {
+	if env.AtFailState(s) {
+		return true
+	}
+	if limited, ok := env.(TimeLimited); ok && limited.OutOfTime() {
+		return false
+	}
+	return env.AtGoalState(s)
+}
+
+// Constructors for the environments, keyed by the name used for the problem
+// parameter in the [environment] section
+var environments = map[string]func(rng *random.Rand) Environment{
+	"cart_pole":    func(rng *random.Rand) Environment { return NewCartPoleEnv(rng) },
+	"mountain_car": func(_ *random.Rand) Environment { return new(MountainCarEnv) },
+}
+
+// make an environment available to configurations under the given problem
+// name, replacing any registered before under that name. Register is meant to
+// be called from init functions, before any environment is created.
+func Register(name string, create func(rng *random.Rand) Environment) {
+	environments[name] = create
+}
+
+// return a new environment for the named problem drawing random numbers from
+// rng, and whether the name is known
+func New(name string, rng *random.Rand) (Environment, bool) {
+	create, ok := environments[name]
+	if !ok {
+		return nil, false
+	}
+	return create(rng), true
+}
+
+// return the names of the known environments, in sorted order
+func Names() []string {
+	names := make([]string, 0, len(environments))
+	for name := range environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// return a new reinforcement learning environment drawing random numbers
+// from rng
+func Create(cfg *config.Config, rng *random.Rand) (Environment, error) {
+	name, err := cfg.String("environment", "problem")
+	if err != nil {
+		return nil, err
+	}
+	env, ok := New(name, rng)
+	if !ok {
+		return nil, &config.ParamError{Section: "environment", Key: "problem",
+			Err: fmt.Errorf("unknown problem '%v' (expected one of %v)", name, Names())}
+	}
+	return env, nil
+}
@@ -0,0 +1,32 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+func TestTerminal(t *testing.T) {
+	// the cart pole's goal is running out of time, which is not terminal
+	cp := NewCartPoleEnv(random.NewRand(1))
+	balanced := space.State{Vals: []float64{0.0, 0.0, 0.0, 0.0}}
+	fallen := space.State{Vals: []float64{0.0, 0.0, 1.0, 0.0}}
+	cp.steps = kMaxSteps
+	if !cp.AtGoalState(balanced) || Terminal(cp, balanced) {
+		t.Error("Running out of time on the cart pole is terminal, or not the goal.\n")
+	}
+	if !Terminal(cp, fallen) {
+		t.Error("Dropping the pole as time runs out is not terminal.\n")
+	}
+	cp.Reset()
+	if Terminal(cp, balanced) || !Terminal(cp, fallen) {
+		t.Error("Cart pole states are terminal other than by failing.\n")
+	}
+
+	// the mountain car's goal is a terminal state
+	mc := new(MountainCarEnv)
+	if !Terminal(mc, space.State{Vals: []float64{0.5, 0.0}}) || Terminal(mc, mc.StartState()) {
+		t.Error("Mountain car states are terminal other than at the goal.\n")
+	}
+}
@@ -1,29 +1,31 @@
-package main
+package environment
 
 import (
 	"math"
+
+	"github.com/deong/gorl/space"
 )
 
 type MountainCarEnv struct {
 	steps uint
 }
 
-var mcFeatureRanges = []Range{
-	Range{-1.4, 1.4}, 			// cart position
-	Range{-0.7, 0.7},			// cart velocity
+var mcFeatureRanges = []space.Range{
+	space.Range{Min: -1.4, Max: 1.4}, 			// cart position
+	space.Range{Min: -0.7, Max: 0.7},			// cart velocity
 }
 
-func (env *MountainCarEnv) Features() (f []Range) {
+func (env *MountainCarEnv) Features() (f []space.Range) {
 	f = mcFeatureRanges
 	return
 }
 
-func (env *MountainCarEnv) ActionRange() Range {
-	return Range{-1.0, 1.0}
+func (env *MountainCarEnv) ActionRange() space.Range {
+	return space.Range{Min: -1.0, Max: 1.0}
 }
 
 // return the immediate reward and new state resulting from taking action a in state s
-func (env *MountainCarEnv) ApplyAction(s State, a Action) (newState State, reward float64) {
+func (env *MountainCarEnv) ApplyAction(s space.State, a space.Action) (newState space.State, reward float64) {
 	// compute the new velocity
 	cartVelocity := s.Vals[1] + (0.001 * a.Val) + (-0.0025 * math.Cos(3.0 * s.Vals[0]))
 	
@@ -44,7 +46,7 @@ func (env *MountainCarEnv) ApplyAction(s State, a Action) (newState State, rewar
 		cartPosition = mcFeatureRanges[0].Max
 	}
 
-	newState = MakeState(2)
+	newState = space.MakeState(2)
 	newState.Vals[0] = cartPosition
 	newState.Vals[1] = cartVelocity
 
@@ -57,7 +59,7 @@ func (env *MountainCarEnv) ApplyAction(s State, a Action) (newState State, rewar
 }
 
 // check if we're at a goal state
-func (_ *MountainCarEnv) AtGoalState(s State) bool {
+func (_ *MountainCarEnv) AtGoalState(s space.State) bool {
 	if s.Vals[0] >= 0.45 {
 		return true
 	}
@@ -65,13 +67,13 @@ func (_ *MountainCarEnv) AtGoalState(s State) bool {
 }
 
 // There is no fail state for the mountain car problem
-func (_ *MountainCarEnv) AtFailState(_ State) bool {
+func (_ *MountainCarEnv) AtFailState(_ space.State) bool {
 	return false
 }
 
 // return a start state
-func (_ *MountainCarEnv) StartState() (s State) {
-	s = State{0, []float64{-0.5, 0.0}}
+func (_ *MountainCarEnv) StartState() (s space.State) {
+	s = space.State{Id: 0, Vals: []float64{-0.5, 0.0}}
 	return
 }
 
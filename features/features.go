@@ -0,0 +1,73 @@
+// Package features maps the continuous states of a problem to vectors of
+// features, over which learners approximate their functions of the state
+// instead of keeping tables over a lattice.
+package features
+
+import (
+	"fmt"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/space"
+)
+
+// A sparse vector of features, holding the indices and values of the
+// features that may be nonzero. An index may appear more than once (e.g.,
+// after hashing), in which case its values add up.
+type Vector struct {
+	Index []int
+	Value []float64
+}
+
+// reset the vector to hold no features, keeping its storage
+func (x *Vector) clear() {
+	x.Index, x.Value = x.Index[:0], x.Value[:0]
+}
+
+// add a feature to the vector
+func (x *Vector) add(i int, v float64) {
+	x.Index = append(x.Index, i)
+	x.Value = append(x.Value, v)
+}
+
+// return the dot product of the vector with a dense vector of weights
+func (x *Vector) Dot(w []float64) float64 {
+	sum := 0.0
+	for k, i := range x.Index {
+		sum += w[i] * x.Value[k]
+	}
+	return sum
+}
+
+// copy the features of another vector into this one, keeping its storage
+func (x *Vector) CopyFrom(y *Vector) {
+	x.Index = append(x.Index[:0], y.Index...)
+	x.Value = append(x.Value[:0], y.Value...)
+}
+
+// An Extractor computes the features of states of a problem
+type Extractor interface {
+	// the number of features, and so the length of a dense weight vector
+	Len() int
+
+	// set x to the features of a state with the given values
+	Extract(vals []float64, x *Vector)
+}
+
+// Build the extractor described by the [features] section for a problem
+// whose state features lie in the given ranges.
+func New(cfg *config.Config, ranges []space.Range) (Extractor, error) {
+	basis, err := cfg.String("features", "basis")
+	if err != nil {
+		return nil, err
+	}
+	switch basis {
+	case "tiles":
+		return newTileCoder(cfg, ranges)
+	case "rbf":
+		return newRBF(cfg, ranges)
+	case "fourier":
+		return newFourier(cfg, ranges)
+	}
+	return nil, &config.ParamError{Section: "features", Key: "basis",
+		Err: fmt.Errorf("unknown basis '%v' (expected tiles, rbf, or fourier)", basis)}
+}
@@ -0,0 +1,84 @@
+package features
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/space"
+)
+
+func TestRBF(t *testing.T) {
+	grid := [][]float64{{0.0, 1.0, 2.0}, {0.0, 10.0}}
+	rbf := NewRBF(grid, 0.25)
+	if rbf.Len() != 6 {
+		t.Errorf("RBF has %v features, expected 6\n", rbf.Len())
+	}
+
+	// one standard deviation from (1, 0) along each feature, and five from
+	// (0, 0) and (0, 10) along the first, which fall below the cutoff
+	var x Vector
+	rbf.Extract([]float64{1.25, 2.5}, &x)
+	values := make(map[int]float64)
+	for k, i := range x.Index {
+		values[i] = x.Value[k]
+	}
+	if expected := math.Exp(-1.0); math.Abs(values[2]-expected) > 1e-12 {
+		t.Errorf("Feature of centre (1, 0) = %v, expected %v\n", values[2], expected)
+	}
+	if _, ok := values[1]; ok || len(x.Index) != 4 {
+		t.Errorf("Features %v include those below the cutoff\n", values)
+	}
+}
+
+func TestFourier(t *testing.T) {
+	ranges := []space.Range{{Min: -1.0, Max: 1.0}, {Min: 0.0, Max: 2.0}}
+	f := NewFourier(ranges, 2)
+	if f.Len() != 9 {
+		t.Errorf("Fourier basis has %v features, expected 9\n", f.Len())
+	}
+
+	// u = (0.5, 0.25): the frequencies are (0, 0), (0, 1), ..., (2, 2)
+	var x Vector
+	f.Extract([]float64{0.0, 0.5}, &x)
+	expected := make([]float64, 0, 9)
+	for _, c0 := range []float64{0, 1, 2} {
+		for _, c1 := range []float64{0, 1, 2} {
+			expected = append(expected, math.Cos(math.Pi*(0.5*c0+0.25*c1)))
+		}
+	}
+	for i := range expected {
+		if x.Index[i] != i || math.Abs(x.Value[i]-expected[i]) > 1e-12 {
+			t.Errorf("Feature %v = %v, expected %v\n", x.Index[i], x.Value[i], expected[i])
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	ranges := []space.Range{{Min: 0.0, Max: 1.0}, {Min: 0.0, Max: 1.0}}
+	cfg := config.New()
+	cfg.Set("environment", "state_grid", "4 5")
+	tests := []struct {
+		basis string
+		n     int
+	}{
+		{"tiles", 8 * 9 * 9},
+		{"rbf", 20},
+		{"fourier", 16},
+	}
+	for _, tt := range tests {
+		cfg.Set("features", "basis", tt.basis)
+		ext, err := New(cfg, ranges)
+		if err != nil {
+			t.Fatalf("Error building %v features: %v\n", tt.basis, err)
+		}
+		if ext.Len() != tt.n {
+			t.Errorf("%v basis has %v features, expected %v\n", tt.basis, ext.Len(), tt.n)
+		}
+	}
+
+	cfg.Set("features", "basis", "wavelets")
+	if _, err := New(cfg, ranges); err == nil {
+		t.Errorf("Expected an error for an unknown basis\n")
+	}
+}
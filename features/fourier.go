@@ -0,0 +1,64 @@
+package features
+
+import (
+	"math"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/space"
+)
+
+// A Fourier basis (Konidaris, Osentoski, and Thomas, 2011) has a feature
+// cos(pi c.u) for every vector c of integer frequencies from 0 to the order
+// along each state feature, where u is the state scaled from the features'
+// ranges to [0, 1]. The features are global, each varying over the whole
+// state space, and the number of them grows as (order + 1) to the power of
+// the number of state features. Values outside of a feature's range are
+// clamped to it.
+type Fourier struct {
+	ranges []space.Range
+	coeffs []space.State // the frequency vectors, as the points of a lattice
+	u      []float64
+}
+
+// return a Fourier basis of the given order over the given ranges
+func NewFourier(ranges []space.Range, order int) *Fourier {
+	freqs := make([]float64, order+1)
+	for i := range freqs {
+		freqs[i] = float64(i)
+	}
+	grid := make([][]float64, len(ranges))
+	for d := range grid {
+		grid[d] = freqs
+	}
+	return &Fourier{ranges: ranges, coeffs: space.BuildLattice(grid), u: make([]float64, len(ranges))}
+}
+
+// build the Fourier basis described by the [features] section
+func newFourier(cfg *config.Config, ranges []space.Range) (*Fourier, error) {
+	order, err := cfg.Uint("features", "order")
+	if err != nil {
+		return nil, err
+	}
+	return NewFourier(ranges, int(order)), nil
+}
+
+// Return the number of features: (order + 1) to the power of the number of
+// state features
+func (self *Fourier) Len() int {
+	return len(self.coeffs)
+}
+
+// Set x to the values of the cosines at a state
+func (self *Fourier) Extract(vals []float64, x *Vector) {
+	for d, r := range self.ranges {
+		self.u[d] = math.Min(math.Max((vals[d]-r.Min)/(r.Max-r.Min), 0.0), 1.0)
+	}
+	x.clear()
+	for i, c := range self.coeffs {
+		dot := 0.0
+		for d, ud := range self.u {
+			dot += c.Vals[d] * ud
+		}
+		x.add(i, math.Cos(math.Pi*dot))
+	}
+}
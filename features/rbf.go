@@ -0,0 +1,78 @@
+package features
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/space"
+)
+
+// the fraction of its peak below which a Gaussian is left out of a state's
+// features
+const rbfCutoff = 1e-4
+
+// An RBF has a feature per point of a lattice, which is a Gaussian centred
+// on the point: its value falls from one at the point with the distance of
+// the state from it. The Gaussians' standard deviation along each feature is
+// width times the spacing of the lattice along it, so that they overlap
+// evenly whatever the features' scales. Unlike tiles, the features vary
+// smoothly with the state. Those falling below rbfCutoff are left out, so a
+// state has only the features of the lattice points around it.
+type RBF struct {
+	centres []space.State
+	sigma   []float64 // the standard deviation along each feature
+}
+
+// return radial basis functions centred on the points of the lattice built
+// from grid, as by BuildLattice, each with a standard deviation of width
+// times the grid's spacing along each feature
+func NewRBF(grid [][]float64, width float64) *RBF {
+	self := &RBF{centres: space.BuildLattice(grid), sigma: make([]float64, len(grid))}
+	for d := range grid {
+		self.sigma[d] = width * (grid[d][1] - grid[d][0])
+	}
+	return self
+}
+
+// build the radial basis functions described by the [features] section,
+// centred on the lattice given by state_grid
+func newRBF(cfg *config.Config, ranges []space.Range) (*RBF, error) {
+	grid, err := space.StateGrid(cfg, ranges)
+	if err != nil {
+		return nil, err
+	}
+	width, err := cfg.Float64("features", "width")
+	if err != nil {
+		return nil, err
+	}
+	if width <= 0.0 {
+		return nil, &config.ParamError{Section: "features", Key: "width",
+			Err: fmt.Errorf("width must be positive, not %v", width)}
+	}
+	return NewRBF(grid, width), nil
+}
+
+// Return the number of features: the number of lattice points
+func (self *RBF) Len() int {
+	return len(self.centres)
+}
+
+// Set x to the values of the Gaussians at a state, leaving out those below
+// the cutoff
+func (self *RBF) Extract(vals []float64, x *Vector) {
+	x.clear()
+	maxDist := -2.0 * math.Log(rbfCutoff)
+	for i, c := range self.centres {
+		dist := 0.0
+		for d, v := range vals {
+			z := (v - c.Vals[d]) / self.sigma[d]
+			if dist += z * z; dist > maxDist {
+				break
+			}
+		}
+		if dist <= maxDist {
+			x.add(i, math.Exp(-0.5*dist))
+		}
+	}
+}
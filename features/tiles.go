@@ -0,0 +1,123 @@
+package features
+
+import (
+	"fmt"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/space"
+)
+
+// A TileCoder covers the state space with a number of tilings, each a grid of
+// tiles over the ranges of the state features, and offset from the others by
+// a fraction of a tile. A state has one feature per tiling, of value one,
+// for the tile containing it, so that nearby states share most of their
+// features and generalize to one another, while distant ones share none.
+// The tilings are offset asymmetrically (by 1, 3, 5, ... times 1/tilings of
+// a tile along successive features; Sutton and Barto, 2018, section 9.5.4),
+// which avoids the diagonal artifacts of uniform offsets.
+//
+// Each tiling has one more tile along each feature than asked for, so that
+// the offset tilings still cover the whole range. Values outside of a
+// feature's range fall into the tiles at its ends. With a hash size, the
+// tiles are hashed into that many features, trading collisions for memory
+// when the number of tiles grows too large with the number of features.
+type TileCoder struct {
+	ranges    []space.Range
+	tilings   int
+	tiles     int // tiles across the range of each feature
+	strides   []int
+	perTiling int
+	hashSize  int
+	coords    []int
+}
+
+// return a tile coder over the given ranges, with no hashing if hashSize is
+// zero
+func NewTileCoder(ranges []space.Range, tilings, tiles, hashSize int) *TileCoder {
+	self := &TileCoder{
+		ranges:   ranges,
+		tilings:  tilings,
+		tiles:    tiles,
+		strides:  make([]int, len(ranges)),
+		hashSize: hashSize,
+		coords:   make([]int, len(ranges)),
+	}
+	self.perTiling = 1
+	for d := range ranges {
+		self.strides[d] = self.perTiling
+		self.perTiling *= tiles + 1
+	}
+	return self
+}
+
+// build the tile coder described by the [features] section
+func newTileCoder(cfg *config.Config, ranges []space.Range) (*TileCoder, error) {
+	tilings, err := cfg.Uint("features", "tilings")
+	if err != nil {
+		return nil, err
+	}
+	if tilings < 1 {
+		return nil, &config.ParamError{Section: "features", Key: "tilings",
+			Err: fmt.Errorf("at least one tiling is needed")}
+	}
+	tiles, err := cfg.Uint("features", "tiles")
+	if err != nil {
+		return nil, err
+	}
+	if tiles < 1 {
+		return nil, &config.ParamError{Section: "features", Key: "tiles",
+			Err: fmt.Errorf("at least one tile per feature is needed")}
+	}
+	hashSize, err := cfg.Uint("features", "hash_size")
+	if err != nil {
+		return nil, err
+	}
+	return NewTileCoder(ranges, int(tilings), int(tiles), int(hashSize)), nil
+}
+
+// Return the number of features: the number of tiles, or the hash size
+func (self *TileCoder) Len() int {
+	if self.hashSize > 0 {
+		return self.hashSize
+	}
+	return self.tilings * self.perTiling
+}
+
+// Set x to the tiles containing a state, one per tiling
+func (self *TileCoder) Extract(vals []float64, x *Vector) {
+	x.clear()
+	for t := 0; t < self.tilings; t++ {
+		for d, r := range self.ranges {
+			// the position in tiles along the feature, and the offset of
+			// this tiling along it
+			u := (vals[d] - r.Min) / (r.Max - r.Min) * float64(self.tiles)
+			if u < 0.0 {
+				u = 0.0
+			} else if u > float64(self.tiles) {
+				u = float64(self.tiles)
+			}
+			offset := float64(t*(2*d+1)%self.tilings) / float64(self.tilings)
+			self.coords[d] = int(u + offset)
+		}
+		x.add(self.index(t), 1.0)
+	}
+}
+
+// return the feature of the tile at the current coordinates in tiling t
+func (self *TileCoder) index(t int) int {
+	if self.hashSize == 0 {
+		i := t * self.perTiling
+		for d, c := range self.coords {
+			i += c * self.strides[d]
+		}
+		return i
+	}
+
+	// FNV-1a over the tiling and coordinates
+	h := uint64(14695981039346656037)
+	h = (h ^ uint64(t)) * 1099511628211
+	for _, c := range self.coords {
+		h = (h ^ uint64(c)) * 1099511628211
+	}
+	return int(h % uint64(self.hashSize))
+}
@@ -0,0 +1,84 @@
+package features
+
+import (
+	"testing"
+
+	"github.com/deong/gorl/space"
+)
+
+// count the features two vectors share
+func shared(x, y *Vector) int {
+	n := 0
+	for _, i := range x.Index {
+		for _, j := range y.Index {
+			if i == j {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+func TestTileCoder(t *testing.T) {
+	ranges := []space.Range{{Min: -1.0, Max: 1.0}, {Min: 0.0, Max: 4.0}}
+	tc := NewTileCoder(ranges, 4, 2, 0)
+	if tc.Len() != 4*3*3 {
+		t.Errorf("Tile coder has %v features, expected 36\n", tc.Len())
+	}
+
+	// one tile per tiling, each within its own tiling
+	var x, y Vector
+	tc.Extract([]float64{0.1, 1.1}, &x)
+	if len(x.Index) != 4 {
+		t.Fatalf("State has %v features, expected 4\n", len(x.Index))
+	}
+	for k, i := range x.Index {
+		if i < k*9 || i >= (k+1)*9 || x.Value[k] != 1.0 {
+			t.Errorf("Feature %v in tiling %v has value %v\n", i, k, x.Value[k])
+		}
+	}
+
+	// a small move changes few tiles, and a move of a whole tile changes all
+	tc.Extract([]float64{0.15, 1.1}, &y)
+	if n := shared(&x, &y); n < 3 {
+		t.Errorf("Nearby states share %v tiles, expected at least 3\n", n)
+	}
+	tc.Extract([]float64{-0.9, 3.1}, &y)
+	if n := shared(&x, &y); n != 0 {
+		t.Errorf("Distant states share %v tiles, expected none\n", n)
+	}
+
+	// values beyond the ranges fall into the tiles at their ends
+	tc.Extract([]float64{-5.0, 100.0}, &x)
+	tc.Extract([]float64{-1.0, 4.0}, &y)
+	if n := shared(&x, &y); n != 4 {
+		t.Errorf("States beyond the ranges share %v tiles with their ends, expected 4\n", n)
+	}
+}
+
+func TestTileCoderHashing(t *testing.T) {
+	ranges := []space.Range{{Min: 0.0, Max: 1.0}, {Min: 0.0, Max: 1.0}, {Min: 0.0, Max: 1.0}}
+	tc := NewTileCoder(ranges, 8, 10, 64)
+	if tc.Len() != 64 {
+		t.Errorf("Hashed tile coder has %v features, expected 64\n", tc.Len())
+	}
+	var x, y Vector
+	tc.Extract([]float64{0.3, 0.6, 0.9}, &x)
+	if len(x.Index) != 8 {
+		t.Fatalf("State has %v features, expected 8\n", len(x.Index))
+	}
+	for _, i := range x.Index {
+		if i < 0 || i >= 64 {
+			t.Errorf("Hashed feature %v is outside of [0, 64)\n", i)
+		}
+	}
+
+	// hashing is deterministic
+	tc.Extract([]float64{0.3, 0.6, 0.9}, &y)
+	for k := range x.Index {
+		if x.Index[k] != y.Index[k] {
+			t.Fatalf("Features %v and %v of the same state differ\n", x.Index, y.Index)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/deong/gorl
+
+go 1.21
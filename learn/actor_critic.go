@@ -0,0 +1,254 @@
+package learn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// Actor-critic over a lattice of states (Sutton and Barto, 2018, sections
+// 13.5 and 13.6). The actor keeps a preference for each action in each state,
+// in Q, and acts by sampling from their softmax at the given temperature; the
+// critic keeps a value for each state, in the table V, and its TD error
+// drives both. The one-step learner updates only the state and action just
+// visited, while the lambda-return learner follows eligibility traces of the
+// critic's states and of the gradient of the log policy. As is usual in
+// practice, the updates are not discounted by the time within the episode.
+// The traces always accumulate, whatever trace is set to.
+//
+// Acting greedily takes the mode of the policy, the action with the highest
+// preference; with follow = sample, gorl run and FollowPolicy sample from it
+// instead.
+type ActorCritic struct {
+	tabular
+	traces      bool
+	temperature float64
+	criticAlpha float64 // the actor's step size is the tabular's alpha
+	sample      bool
+
+	probs        []float64 // the policy in the current state
+	criticE      []float64 // the critic's trace, by state
+	criticActive []uint    // the states whose critic trace is nonzero
+}
+
+// return a one-step actor-critic learner drawing random numbers from rng
+func NewActorCritic(rng *random.Rand) *ActorCritic {
+	return &ActorCritic{tabular: tabular{episodic: episodic{name: "actor_critic", rng: rng}}}
+}
+
+// return a lambda-return actor-critic learner drawing random numbers from rng
+func NewActorCriticLambda(rng *random.Rand) *ActorCritic {
+	return &ActorCritic{tabular: tabular{episodic: episodic{name: "actor_critic_lambda", rng: rng}}, traces: true}
+}
+
+// Initialize the action preferences, the critic's state values, and their
+// traces.
+func (self *ActorCritic) Init(cfg *config.Config, env environment.Environment) (err error) {
+	if err = self.init(cfg, env); err != nil {
+		return
+	}
+	if self.alpha, err = cfg.Float64("learning", "actor_alpha"); err != nil {
+		return
+	}
+	if self.criticAlpha, err = cfg.Float64("learning", "critic_alpha"); err != nil {
+		return
+	}
+	if self.temperature, err = cfg.Float64("learning", "temperature"); err != nil {
+		return
+	}
+	if self.temperature <= 0.0 {
+		return &config.ParamError{Section: "learning", Key: "temperature",
+			Err: fmt.Errorf("temperature must be positive, not %v", self.temperature)}
+	}
+	follow, err := cfg.String("learning", "follow")
+	if err != nil {
+		return
+	}
+	if follow != "mode" && follow != "sample" {
+		return &config.ParamError{Section: "learning", Key: "follow",
+			Err: fmt.Errorf("unknown follow '%v' (expected mode or sample)", follow)}
+	}
+	self.sample = follow == "sample"
+	if !self.traces {
+		self.lambda = 0.0
+	}
+
+	values := make([][]float64, len(self.states))
+	for i := range values {
+		values[i] = make([]float64, 1)
+	}
+	self.tables = map[string][][]float64{"V": values}
+	return nil
+}
+
+// Return the probabilities with which the policy takes each action in state
+// s. The slice is reused by the next call.
+func (self *ActorCritic) Probabilities(s space.State) []float64 {
+	prefs := self.Q[s.Id]
+	if len(self.probs) != len(prefs) {
+		self.probs = make([]float64, len(prefs))
+	}
+	softmax(prefs, self.temperature, self.probs)
+	return self.probs
+}
+
+// Return an action sampled from the policy in state s
+func (self *ActorCritic) SampleAction(s space.State) uint {
+	return sample(self.Probabilities(s), self.rng)
+}
+
+// set probs to the softmax of a set of preferences at the given temperature
+func softmax(prefs []float64, temperature float64, probs []float64) {
+	max := prefs[0]
+	for _, h := range prefs[1:] {
+		max = math.Max(max, h)
+	}
+	sum := 0.0
+	for i, h := range prefs {
+		probs[i] = math.Exp((h - max) / temperature)
+		sum += probs[i]
+	}
+	for i := range probs {
+		probs[i] /= sum
+	}
+}
+
+// return an index drawn from a discrete probability distribution
+func sample(probs []float64, rng *random.Rand) uint {
+	u := rng.Float64()
+	for i, p := range probs {
+		if u < p {
+			return uint(i)
+		}
+		u -= p
+	}
+	return uint(len(probs) - 1)
+}
+
+// Return the action taken when following the policy in an arbitrary
+// continuous state: sampled from the policy with follow = sample, and its
+// mode otherwise
+func (self *ActorCritic) FollowAction(s space.State) space.Action {
+	if !self.sample {
+		return self.GreedyAction(s)
+	}
+	self.DiscretizeState(&s)
+	return self.actions[self.SampleAction(s)]
+}
+
+// Follow the policy for an episode, sampling from it or taking its mode as
+// set by follow, and printing each action taken
+func (self *ActorCritic) FollowPolicy(env environment.Environment) {
+	self.follow(env, self.FollowAction)
+}
+
+// clear the traces of the actor and critic, as at the start of an episode
+func (self *ActorCritic) clearTraces() {
+	self.clearTrace()
+	for _, s := range self.criticActive {
+		self.criticE[s] = 0.0
+	}
+	self.criticActive = self.criticActive[:0]
+}
+
+// mark the visit to state s and action a in the traces: the critic's trace
+// of s grows by one, and the actor's traces in s by the gradient of the log
+// probability of a
+func (self *ActorCritic) mark(s space.State, a uint, probs []float64) {
+	if self.criticE[s.Id] == 0.0 {
+		self.criticActive = append(self.criticActive, s.Id)
+	}
+	self.criticE[s.Id] += 1.0
+	for b, p := range probs {
+		if self.E[s.Id][b] == 0.0 {
+			self.active = append(self.active, pair{s.Id, uint(b)})
+		}
+		if uint(b) == a {
+			self.E[s.Id][b] += (1.0 - p) / self.temperature
+		} else {
+			self.E[s.Id][b] -= p / self.temperature
+		}
+	}
+}
+
+// update the critic's values by its step size times delta times their
+// traces, then decay the traces, as traceUpdate does for the actor
+func (self *ActorCritic) criticUpdate(delta float64) {
+	values := self.tables["V"]
+	for k := 0; k < len(self.criticActive); {
+		s := self.criticActive[k]
+		values[s][0] += self.criticAlpha * delta * self.criticE[s]
+		self.criticE[s] *= self.gamma * self.lambda
+		if self.criticE[s] <= self.threshold {
+			self.criticE[s] = 0.0
+			last := len(self.criticActive) - 1
+			self.criticActive[k] = self.criticActive[last]
+			self.criticActive = self.criticActive[:last]
+			continue
+		}
+		k++
+	}
+}
+
+// Learn the policy
+func (self *ActorCritic) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	// the critic's trace is sized here, as a policy or checkpoint loaded
+	// after Init may have replaced the lattice
+	if len(self.criticE) != len(self.states) {
+		self.criticE, self.criticActive = make([]float64, len(self.states)), nil
+	}
+	values := self.tables["V"]
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		self.DiscretizeState(&s)
+		self.clearTraces()
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+
+			// sample an action from the policy
+			aIndex := self.SampleAction(s)
+			a := self.actions[aIndex]
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, a)
+			self.DiscretizeState(&sp)
+
+			// the critic's TD error, bootstrapping unless the episode has ended
+			delta := reward - values[s.Id][0]
+			if !env.AtGoalState(sp) && !env.AtFailState(sp) {
+				delta += self.gamma * values[sp.Id][0]
+			}
+
+			// update the critic and actor through their traces, which with
+			// lambda = 0 reach only the state and action just visited
+			self.mark(s, aIndex, self.probs)
+			self.criticUpdate(delta)
+			self.traceUpdate(delta)
+
+			s = sp
+			stats.Step(reward, delta)
+		}
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package learn
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+func TestActorCriticProbabilities(t *testing.T) {
+	lrn := NewActorCritic(random.NewRand(1))
+	lrn.Q = [][]float64{{0.0, math.Log(2.0), math.Log(5.0)}}
+	s := space.State{Id: 0}
+	tests := []struct {
+		temperature float64
+		expected    []float64
+	}{
+		{1.0, []float64{0.125, 0.25, 0.625}},
+		{math.Inf(1), []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}},
+	}
+	for _, tt := range tests {
+		lrn.temperature = tt.temperature
+		if probs := lrn.Probabilities(s); !vectorEpsilonEqual(probs, tt.expected, 1e-12) {
+			t.Errorf("Probabilities at temperature %v = %v, expected %v\n", tt.temperature, probs, tt.expected)
+		}
+	}
+
+	// the mode is the action with the highest preference
+	if a, _ := lrn.ArgmaxAction(s); a != 2 {
+		t.Errorf("Greedy action %v, expected 2\n", a)
+	}
+}
+
+func TestActorCriticMark(t *testing.T) {
+	lrn := NewActorCritic(random.NewRand(1))
+	lrn.temperature = 0.5
+	lrn.E = [][]float64{{0.0, 0.0, 0.0}, {0.0, 0.0, 0.0}}
+	lrn.criticE = []float64{0.0, 0.0}
+	lrn.mark(space.State{Id: 1}, 2, []float64{0.125, 0.25, 0.625})
+
+	// the gradient of the log probability of the action taken
+	expected := []float64{-0.25, -0.5, 0.75}
+	if !vectorEpsilonEqual(lrn.E[1], expected, 1e-12) || lrn.criticE[1] != 1.0 {
+		t.Errorf("Traces after marking = %v and %v, expected %v and [0 1]\n", lrn.E[1], lrn.criticE, expected)
+	}
+	if len(lrn.active) != 3 || len(lrn.criticActive) != 1 {
+		t.Errorf("%v actor and %v critic entries active, expected 3 and 1\n", len(lrn.active), len(lrn.criticActive))
+	}
+}
+
+func TestActorCriticFollow(t *testing.T) {
+	lrn := NewActorCritic(random.NewRand(1))
+	lrn.states = []space.State{{Id: 0, Vals: []float64{0.0}}}
+	lrn.actions = []space.Action{{Id: 0, Val: -1.0}, {Id: 1, Val: 1.0}}
+	lrn.Q = [][]float64{{0.0, math.Log(3.0)}}
+	lrn.temperature = 1.0
+	s := space.State{Vals: []float64{0.0}}
+
+	// the mode is always action 1, while sampling takes action 0 a quarter
+	// of the time
+	for _, sample := range []bool{false, true} {
+		lrn.sample = sample
+		policy := Follow(lrn)
+		counts := make([]int, 2)
+		for i := 0; i < 1000; i++ {
+			counts[policy(s).Id]++
+		}
+		if !sample && counts[0] != 0 {
+			t.Errorf("Following the mode took action 0 %v times in 1000, expected never\n", counts[0])
+		}
+		if sample && (counts[0] < 200 || counts[0] > 300) {
+			t.Errorf("Sampling took action 0 %v times in 1000, expected about 250\n", counts[0])
+		}
+	}
+}
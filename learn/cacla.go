@@ -0,0 +1,291 @@
+package learn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/features"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// CACLA, the Continuous Actor Critic Learning Automaton (van Hasselt and
+// Wiering, 2007): an actor-critic whose actions are real values anywhere in
+// the environment's ActionRange(), rather than points of the action_grid.
+// The actor's action and the critic's value of a state are both linear in
+// its features, as built from the [features] section. Actions are explored
+// by adding Gaussian noise with standard deviation sigma to the actor's
+// action and clipping the result to the range. The critic learns by TD(0)
+// with step size critic_alpha, and whenever the TD error is positive, so that
+// the action taken turned out better than expected, the actor's action takes
+// a step of size actor_alpha towards it. Only the sign of the error is used,
+// which makes the actor's steps independent of the scale of the rewards.
+//
+// Acting greedily takes the actor's action; with follow = sample, gorl run
+// and FollowPolicy add the exploration noise as in training. With no discrete
+// actions to choose from, Actions is empty, and the action indices returned
+// by ArgmaxAction and its kin are always zero, along with the critic's value
+// of the state.
+type CACLA struct {
+	episodic
+	actionRange space.Range
+	extractor   features.Extractor
+	actor       []float64 // the weights of the actor's action
+	critic      []float64 // the weights of the critic's state value
+	alpha       float64
+	criticAlpha float64
+	gamma       float64
+	sigma       float64
+	sample      bool
+
+	x, xp features.Vector // the features of the current and next states
+}
+
+// return a CACLA learner drawing random numbers from rng
+func NewCACLA(rng *random.Rand) *CACLA {
+	return &CACLA{episodic: episodic{name: "cacla", rng: rng}}
+}
+
+// Build the feature extractor, initialize the weights, and read the learning
+// parameters.
+func (self *CACLA) Init(cfg *config.Config, env environment.Environment) (err error) {
+	if err = self.initEpisodes(cfg); err != nil {
+		return
+	}
+	self.actionRange = env.ActionRange()
+	if self.extractor, err = features.New(cfg, env.Features()); err != nil {
+		return
+	}
+	self.actor = make([]float64, self.extractor.Len())
+	self.critic = make([]float64, self.extractor.Len())
+
+	if self.alpha, err = cfg.Float64("learning", "actor_alpha"); err != nil {
+		return
+	}
+	if self.criticAlpha, err = cfg.Float64("learning", "critic_alpha"); err != nil {
+		return
+	}
+	if self.gamma, err = cfg.Float64("learning", "gamma"); err != nil {
+		return
+	}
+	if self.sigma, err = cfg.Float64("learning", "sigma"); err != nil {
+		return
+	}
+	follow, err := cfg.String("learning", "follow")
+	if err != nil {
+		return
+	}
+	if follow != "mode" && follow != "sample" {
+		return &config.ParamError{Section: "learning", Key: "follow",
+			Err: fmt.Errorf("unknown follow '%v' (expected mode or sample)", follow)}
+	}
+	self.sample = follow == "sample"
+	return nil
+}
+
+// return the action value v clipped to the action range
+func (self *CACLA) clip(v float64) float64 {
+	return math.Max(self.actionRange.Min, math.Min(self.actionRange.Max, v))
+}
+
+// return the actor's action in a state with features x, before clipping
+func (self *CACLA) mean(x *features.Vector) float64 {
+	return x.Dot(self.actor)
+}
+
+// return the actor's action with exploration noise added, clipped to the
+// action range
+func (self *CACLA) explore(x *features.Vector) float64 {
+	return self.clip(self.mean(x) + self.sigma*self.rng.NormFloat64())
+}
+
+// Return the critic's value of a state; the index is always zero
+func (self *CACLA) ArgmaxAction(s space.State) (indexOfBest uint, valueOfBest float64) {
+	self.extractor.Extract(s.Vals, &self.x)
+	return 0, self.x.Dot(self.critic)
+}
+
+// Return the critic's value of a state; the index is always zero
+func (self *CACLA) RandomAction(s space.State) (indexOfBest uint, valueOfBest float64) {
+	return self.ArgmaxAction(s)
+}
+
+// Return the critic's value of a state, and whether epsilon would have the
+// action chosen greedily; the index is always zero
+func (self *CACLA) EpsilonGreedyAction(s space.State, epsilon float64) (indexOfBest uint, valueOfBest float64, wasGreedy bool) {
+	indexOfBest, valueOfBest = self.ArgmaxAction(s)
+	return indexOfBest, valueOfBest, self.rng.Float64() >= epsilon
+}
+
+// Return no actions, as the learner acts anywhere in the action range
+func (self *CACLA) Actions() []space.Action {
+	return nil
+}
+
+// Return the actor's action in a state, clipped to the action range
+func (self *CACLA) GreedyAction(s space.State) space.Action {
+	self.extractor.Extract(s.Vals, &self.x)
+	return space.Action{Val: self.clip(self.mean(&self.x)), Argmax: true}
+}
+
+// Return the action taken when following the policy in a state: the actor's
+// action with exploration noise added with follow = sample, and without it
+// otherwise
+func (self *CACLA) FollowAction(s space.State) space.Action {
+	a := self.GreedyAction(s)
+	if self.sample {
+		a = space.Action{Val: self.explore(&self.x)}
+	}
+	return a
+}
+
+// Follow the policy for an episode, adding exploration noise or taking the
+// actor's action as set by follow, and printing each step
+func (self *CACLA) FollowPolicy(env environment.Environment) {
+	self.follow(env, self.FollowAction)
+}
+
+// the weights, as saved in policies and checkpoints
+func (self *CACLA) weights() map[string][][]float64 {
+	return map[string][][]float64{"actor": {self.actor}, "critic": {self.critic}}
+}
+
+// replace the weights with those of a saved policy, which must have been
+// learned over the same features
+func (self *CACLA) loadWeights(p *Policy) error {
+	if len(p.Actions) != 0 {
+		return fmt.Errorf("policy learned by %v has discrete actions", p.Learner)
+	}
+	w := make(map[string][]float64)
+	for _, name := range []string{"actor", "critic"} {
+		rows, ok := p.Weights[name]
+		if !ok || len(rows) != 1 {
+			return fmt.Errorf("policy learned by %v has no %v weights", p.Learner, name)
+		}
+		if len(rows[0]) != self.extractor.Len() {
+			return fmt.Errorf("policy was learned over %v features, not %v", len(rows[0]), self.extractor.Len())
+		}
+		w[name] = rows[0]
+	}
+	self.actor, self.critic = w["actor"], w["critic"]
+	return nil
+}
+
+// Save the weights to a file
+func (self *CACLA) SavePolicy(filename string) error {
+	p := &Policy{
+		Learner: self.name,
+		Weights: self.weights(),
+		Params: map[string]float64{
+			"actor_alpha":  self.alpha,
+			"critic_alpha": self.criticAlpha,
+			"gamma":        self.gamma,
+			"sigma":        self.sigma,
+			"action_min":   self.actionRange.Min,
+			"action_max":   self.actionRange.Max,
+		},
+	}
+	return WritePolicy(filename, p)
+}
+
+// Replace the weights with those from a saved policy. Must be called after
+// Init, with the same features as the policy was learned over; the learning
+// parameters from the configuration file are left untouched.
+func (self *CACLA) LoadPolicy(filename string) error {
+	p, err := ReadPolicy(filename)
+	if err != nil {
+		return err
+	}
+	if err = self.loadWeights(p); err != nil {
+		return fmt.Errorf("error loading policy from '%v': %v", filename, err)
+	}
+	return nil
+}
+
+// Save the complete training state so that learning can be resumed later
+func (self *CACLA) SaveCheckpoint(filename string, env environment.Environment) error {
+	c := self.checkpoint(env)
+	c.Policy.Weights = self.weights()
+	return WriteCheckpoint(filename, c)
+}
+
+// Restore the training state saved by SaveCheckpoint. Must be called after
+// Init; a subsequent call to Learn continues from the epoch following the
+// checkpoint.
+func (self *CACLA) LoadCheckpoint(filename string, env environment.Environment) error {
+	c, err := self.readCheckpoint(filename)
+	if err != nil {
+		return err
+	}
+	if err = self.loadWeights(&c.Policy); err != nil {
+		return fmt.Errorf("error loading checkpoint from '%v': %v", filename, err)
+	}
+	self.resume(c, env)
+	return nil
+}
+
+// Learn the actor's and critic's weights
+func (self *CACLA) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		self.extractor.Extract(s.Vals, &self.x)
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+
+			// explore around the actor's action
+			a := self.explore(&self.x)
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, space.Action{Val: a})
+			delta := self.step(&self.x, a, sp, reward, env.AtGoalState(sp) || env.AtFailState(sp))
+
+			s = sp
+			self.x, self.xp = self.xp, self.x
+			stats.Step(reward, delta)
+		}
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// update the critic, and the actor if the TD error is positive, after taking
+// action a in a state with features x and moving to state sp with the given
+// reward. The features of sp are left in xp. Returns the TD error.
+func (self *CACLA) step(x *features.Vector, a float64, sp space.State, reward float64, terminal bool) float64 {
+	self.extractor.Extract(sp.Vals, &self.xp)
+	target := reward
+	if !terminal {
+		target += self.gamma * self.xp.Dot(self.critic)
+	}
+	delta := target - x.Dot(self.critic)
+
+	// the actor moves towards a using its action from before the update
+	if delta > 0.0 {
+		diff := a - self.mean(x)
+		for i, f := range x.Index {
+			self.actor[f] += self.alpha * diff * x.Value[i]
+		}
+	}
+	for i, f := range x.Index {
+		self.critic[f] += self.criticAlpha * delta * x.Value[i]
+	}
+	return delta
+}
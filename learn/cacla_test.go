@@ -0,0 +1,143 @@
+package learn
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/features"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// the features of a state are its values, each at its own index
+type identityFeatures int
+
+func (n identityFeatures) Len() int { return int(n) }
+
+func (n identityFeatures) Extract(vals []float64, x *features.Vector) {
+	x.Index, x.Value = x.Index[:0], x.Value[:0]
+	for i, v := range vals {
+		x.Index = append(x.Index, i)
+		x.Value = append(x.Value, v)
+	}
+}
+
+func newTestCACLA() *CACLA {
+	lrn := NewCACLA(random.NewRand(1))
+	lrn.extractor = identityFeatures(2)
+	lrn.actionRange = space.Range{Min: -1.0, Max: 1.0}
+	lrn.actor = []float64{0.5, 0.0}
+	lrn.critic = []float64{1.0, 0.0}
+	lrn.alpha, lrn.criticAlpha, lrn.gamma = 0.5, 0.25, 0.5
+	return lrn
+}
+
+func TestCACLAStep(t *testing.T) {
+	x := &features.Vector{Index: []int{0, 1}, Value: []float64{1.0, 1.0}}
+	sp := space.State{Vals: []float64{0.0, 2.0}}
+	tests := []struct {
+		reward   float64
+		terminal bool
+		delta    float64
+		actor    []float64
+		critic   []float64
+	}{
+		// the next state is worth nothing to the critic, so the TD error is
+		// the reward less the current state's value of 1; only when it is
+		// positive does the actor's action of 0.5 move to 0.9, as alpha x.x is 1
+		{2.0, false, 1.0, []float64{0.7, 0.2}, []float64{1.25, 0.25}},
+		{0.5, false, -0.5, []float64{0.5, 0.0}, []float64{0.875, -0.125}},
+		{2.0, true, 1.0, []float64{0.7, 0.2}, []float64{1.25, 0.25}},
+	}
+	for _, tt := range tests {
+		lrn := newTestCACLA()
+		if delta := lrn.step(x, 0.9, sp, tt.reward, tt.terminal); delta != tt.delta {
+			t.Errorf("TD error after reward %v = %v, expected %v\n", tt.reward, delta, tt.delta)
+		}
+		if !vectorEpsilonEqual(lrn.actor, tt.actor, 1e-12) || !vectorEpsilonEqual(lrn.critic, tt.critic, 1e-12) {
+			t.Errorf("Weights after reward %v = %v and %v, expected %v and %v\n",
+				tt.reward, lrn.actor, lrn.critic, tt.actor, tt.critic)
+		}
+	}
+}
+
+func TestCACLAGreedyAction(t *testing.T) {
+	lrn := newTestCACLA()
+	tests := []struct {
+		vals     []float64
+		expected float64
+	}{
+		{[]float64{1.0, 0.0}, 0.5},
+		{[]float64{4.0, 0.0}, 1.0},
+		{[]float64{-3.0, 0.0}, -1.0},
+	}
+	for _, tt := range tests {
+		if a := lrn.GreedyAction(space.State{Vals: tt.vals}); a.Val != tt.expected {
+			t.Errorf("GreedyAction(%v) = %v, expected %v\n", tt.vals, a.Val, tt.expected)
+		}
+	}
+
+	// gorl run explores as in training only with follow = sample
+	s := space.State{Vals: []float64{1.5, 0.0}}
+	lrn.sigma = 0.5
+	for _, sample := range []bool{false, true} {
+		lrn.sample = sample
+		policy := Follow(lrn)
+		varied := false
+		for i := 0; i < 100; i++ {
+			v := policy(s).Val
+			if v < -1.0 || v > 1.0 {
+				t.Fatalf("Followed action %v is outside the action range\n", v)
+			}
+			varied = varied || v != 0.75
+		}
+		if varied != sample {
+			t.Errorf("Following with sample = %v varied the actor's action: %v\n", sample, varied)
+		}
+	}
+}
+
+func TestCACLAPolicy(t *testing.T) {
+	lrn := newTestCACLA()
+	filename := filepath.Join(t.TempDir(), "policy.json")
+	if err := lrn.SavePolicy(filename); err != nil {
+		t.Fatalf("Error saving policy: %v\n", err)
+	}
+	loaded := newTestCACLA()
+	loaded.actor, loaded.critic = []float64{0.0, 0.0}, []float64{0.0, 0.0}
+	if err := loaded.LoadPolicy(filename); err != nil {
+		t.Fatalf("Error loading policy: %v\n", err)
+	}
+	if !vectorEpsilonEqual(loaded.actor, lrn.actor, 1e-12) || !vectorEpsilonEqual(loaded.critic, lrn.critic, 1e-12) {
+		t.Errorf("Loaded weights %v and %v, expected %v and %v\n", loaded.actor, loaded.critic, lrn.actor, lrn.critic)
+	}
+	loaded.extractor = identityFeatures(3)
+	if err := loaded.LoadPolicy(filename); err == nil {
+		t.Errorf("Loaded a policy learned over 2 features into a learner over 3\n")
+	}
+}
+
+func TestCACLAFollowPolicy(t *testing.T) {
+	cfg, err := config.Parse(strings.NewReader(checkpointTestConfig), "test.cfg")
+	if err != nil {
+		t.Fatalf("Error parsing configuration: %v\n", err)
+	}
+	cfg.Set("environment", "problem", "mountain_car")
+	cfg.Set("environment", "state_grid", "3 3")
+	cfg.Set("environment", "max_steps", "50")
+	cfg.Set("learning", "learner", "cacla")
+
+	// an untrained actor pushes with no force, and never reaches the goal
+	env := &recordingEnv{Environment: new(environment.MountainCarEnv)}
+	lrn := NewCACLA(random.NewRand(1))
+	if err := lrn.Init(cfg, env); err != nil {
+		t.Fatalf("Error initializing learner: %v\n", err)
+	}
+	lrn.FollowPolicy(env)
+	if len(env.rewards) != 50 {
+		t.Errorf("Following the policy took %v steps: expected the limit of 50.\n", len(env.rewards))
+	}
+}
@@ -0,0 +1,87 @@
+package learn
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/deong/gorl/environment"
+)
+
+// Checkpoints share the JSON encoding of policies but carry their own format
+// version, since they hold additional training state.
+const CheckpointFormatVersion = 2
+
+// A Checkpoint is the complete state of a learner part of the way through
+// training: its policy plus everything needed to continue learning exactly
+// where it left off.
+type Checkpoint struct {
+	Version   int
+	Epoch     uint
+	Epsilon   float64
+	RandState uint64
+	Policy    Policy
+	E         [][]float64
+
+	// the transitions observed by learners that learn a model, in the order
+	// they were first observed
+	Model []Transition `json:",omitempty"`
+
+	// the pairs queued by prioritized sweeping, in heap order
+	Queue []Priority `json:",omitempty"`
+
+	// further training state of learners without tables, in an encoding of
+	// their own (e.g., the target network, optimizer, and replay buffer of dqn)
+	Extra json.RawMessage `json:",omitempty"`
+
+	// state of the environment's generator, for environments that are Stochastic
+	EnvRandState uint64
+}
+
+// Learners that can save and restore their complete training state
+type Checkpointer interface {
+	SaveCheckpoint(filename string, env environment.Environment) error
+	LoadCheckpoint(filename string, env environment.Environment) error
+}
+
+// write a checkpoint to the named file
+func WriteCheckpoint(filename string, c *Checkpoint) error {
+	c.Version = CheckpointFormatVersion
+	c.Policy.Version = PolicyFormatVersion
+	if err := writeJSON(filename, c); err != nil {
+		return fmt.Errorf("error saving checkpoint: %v", err)
+	}
+	return nil
+}
+
+// read a checkpoint from the named file, checking that it is internally consistent
+func ReadCheckpoint(filename string) (c *Checkpoint, err error) {
+	var f *os.File
+	if f, err = os.Open(filename); err != nil {
+		return nil, fmt.Errorf("error loading checkpoint: %v", err)
+	}
+	defer f.Close()
+
+	c = new(Checkpoint)
+	if err = json.NewDecoder(f).Decode(c); err != nil {
+		return nil, fmt.Errorf("error loading checkpoint from '%v': %v", filename, err)
+	}
+	if c.Version != CheckpointFormatVersion {
+		return nil, fmt.Errorf("error loading checkpoint from '%v': unsupported format version %v (expected %v)",
+			filename, c.Version, CheckpointFormatVersion)
+	}
+	if err = c.Policy.check(); err != nil {
+		return nil, fmt.Errorf("error loading checkpoint from '%v': %v", filename, err)
+	}
+	if c.E != nil && len(c.E) != len(c.Policy.Q) {
+		return nil, fmt.Errorf("error loading checkpoint from '%v': trace has %v rows but there are %v states",
+			filename, len(c.E), len(c.Policy.Q))
+	}
+	for i := range c.E {
+		if len(c.E[i]) != len(c.Policy.Actions) {
+			return nil, fmt.Errorf("error loading checkpoint from '%v': trace row %v has %v entries but there are %v actions",
+				filename, i, len(c.E[i]), len(c.Policy.Actions))
+		}
+	}
+	return
+}
@@ -0,0 +1,140 @@
+package learn
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/random"
+)
+
+const checkpointTestConfig = `[environment]
+problem = cart_pole
+state_grid = 3 3 3 3
+action_grid = 3
+
+[learning]
+learner = qlearning
+lambda = 0.9
+alpha = 0.5
+gamma = 0.99
+epsilon = 0.2
+epochs = 4
+seed = 17
+`
+
+// the learners whose checkpoints are tested, and any changes they need to
+// the test configuration
+var checkpointTests = []struct {
+	name string
+	set  func(cfg *config.Config)
+}{
+	{"qlearning", nil},
+	{"sarsa", nil},
+	{"expected_sarsa", nil},
+	{"double_qlearning", nil},
+	{"monte_carlo", nil},
+	{"off_policy_monte_carlo", nil},
+	{"dyna_q", nil},
+	{"prioritized_sweeping", nil},
+	{"actor_critic", nil},
+	{"actor_critic_lambda", nil},
+	{"reinforce_baseline", nil},
+	{"linear_sarsa", nil},
+	{"linear_qlearning", nil},
+	{"dqn", func(cfg *config.Config) {
+		cfg.Set("network", "hidden", "8")
+		cfg.Set("learning", "replay_start", "10")
+		cfg.Set("learning", "batch_size", "4")
+		cfg.Set("learning", "replay_size", "50")
+		cfg.Set("learning", "target_interval", "7")
+	}},
+	{"rlearning", func(cfg *config.Config) {
+		cfg.Set("learning", "reward_window", "7")
+	}},
+	{"cacla", nil},
+}
+
+func TestCheckpointResume(t *testing.T) {
+	for _, tt := range checkpointTests {
+		t.Run(tt.name, func(t *testing.T) { testCheckpointResume(t, tt.name, tt.set) })
+	}
+}
+
+// train the named learner for four epochs without interruption, and again
+// for two epochs, from a checkpoint of which a fresh learner and environment
+// train for two more. Both runs must end in the same state, as saved in a
+// checkpoint: the same schedule, generators, policy, and whatever further
+// training state the learner keeps. If set is not nil, it may change the
+// configuration first.
+func testCheckpointResume(t *testing.T, name string, set func(cfg *config.Config)) {
+	cfg, err := config.Parse(strings.NewReader(checkpointTestConfig), "test.cfg")
+	if err != nil {
+		t.Fatalf("Error parsing configuration: %v\n", err)
+	}
+	cfg.Set("learning", "learner", name)
+	if set != nil {
+		set(cfg)
+	}
+	seed, _, _ := random.RunSeed(cfg)
+	envSeed, lrnSeed := random.StreamSeed(seed, random.EnvironmentStream), random.StreamSeed(seed, random.LearnerStream)
+	dir := t.TempDir()
+
+	// train with fresh generators, from a checkpoint if one is given, saving
+	// a checkpoint of the end of training
+	train := func(envSeed, lrnSeed int64, epochs, from, to string) {
+		env := environment.NewCartPoleEnv(random.NewRand(envSeed))
+		lrn, ok := New(name, random.NewRand(lrnSeed))
+		if !ok {
+			t.Fatalf("Unknown learner %v\n", name)
+		}
+		cfg.Set("learning", "epochs", epochs)
+		if err := lrn.Init(cfg, env); err != nil {
+			t.Fatalf("Error initializing learner: %v\n", err)
+		}
+		// through the learner, so that learners keeping further training
+		// state (e.g., the model of dyna_q) restore and save it
+		if from != "" {
+			if err := lrn.(Checkpointer).LoadCheckpoint(from, env); err != nil {
+				t.Fatalf("Error loading checkpoint: %v\n", err)
+			}
+		}
+		if err := lrn.Learn(env); err != nil {
+			t.Fatalf("Error learning: %v\n", err)
+		}
+		if err := lrn.(Checkpointer).SaveCheckpoint(to, env); err != nil {
+			t.Fatalf("Error saving checkpoint: %v\n", err)
+		}
+	}
+
+	full, half, resumed := filepath.Join(dir, "full.json"), filepath.Join(dir, "half.json"), filepath.Join(dir, "resumed.json")
+	train(envSeed, lrnSeed, "4", "", full)
+	train(envSeed, lrnSeed, "2", "", half)
+	if c, err := ReadCheckpoint(half); err != nil {
+		t.Fatalf("Error reading checkpoint: %v\n", err)
+	} else if c.Epoch != 2 {
+		t.Errorf("Checkpoint after two epochs is at epoch %v: expected 2.\n", c.Epoch)
+	}
+	train(0, 0, "4", half, resumed)
+
+	f, err := os.ReadFile(full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := os.ReadFile(resumed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, f) {
+		i := 0
+		for i < len(r) && i < len(f) && r[i] == f[i] {
+			i++
+		}
+		t.Errorf("Resumed run ended in a different state from the uninterrupted one, first at ...%s: expected ...%s\n",
+			r[max(i-60, 0):min(i+60, len(r))], f[max(i-60, 0):min(i+60, len(f))])
+	}
+}
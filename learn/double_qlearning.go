@@ -0,0 +1,160 @@
+package learn
+
+import (
+	"fmt"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// Double Q-learning (van Hasselt, 2010): two tables of action values, A and
+// B, are learned from the same experience. They are updated alternately, one
+// per step, starting with A: the table updated moves towards the value the
+// other gives to its own greedy action in the next state, which removes the
+// maximization bias of Q-learning. Actions are chosen from the sum of the
+// tables, which is kept in Q. The update is one-step; lambda is not used.
+type DoubleQLearning struct {
+	tabular
+	updateB bool // whether B rather than A is updated next
+}
+
+// return a Double Q-learning learner drawing random numbers from rng
+func NewDoubleQLearning(rng *random.Rand) *DoubleQLearning {
+	return &DoubleQLearning{tabular: tabular{episodic: episodic{name: "double_qlearning", rng: rng}}}
+}
+
+// Initialize the two tables, their sum, and the trace.
+func (self *DoubleQLearning) Init(cfg *config.Config, env environment.Environment) error {
+	if err := self.init(cfg, env); err != nil {
+		return err
+	}
+	self.tables = make(map[string][][]float64)
+	for _, name := range []string{"A", "B"} {
+		table := make([][]float64, len(self.Q))
+		for i := range table {
+			table[i] = make([]float64, len(self.actions))
+		}
+		self.tables[name] = table
+	}
+	self.updateB = false
+	return nil
+}
+
+// Load a saved policy. If its tables do not sum to Q, as when it was saved by
+// a learner keeping Q alone, Q is split evenly between them.
+func (self *DoubleQLearning) LoadPolicy(filename string) error {
+	if err := self.tabular.LoadPolicy(filename); err != nil {
+		return err
+	}
+	a, b := self.tables["A"], self.tables["B"]
+	for i := range self.Q {
+		for j := range self.Q[i] {
+			if a[i][j]+b[i][j] != self.Q[i][j] {
+				a[i][j], b[i][j] = self.Q[i][j]/2.0, self.Q[i][j]/2.0
+			}
+		}
+	}
+	return nil
+}
+
+// Save the complete training state, including which table is updated next,
+// so that learning can be resumed later
+func (self *DoubleQLearning) SaveCheckpoint(filename string, env environment.Environment) error {
+	c := self.tableCheckpoint(env)
+	c.Policy.Params = map[string]float64{"next_table": 0.0}
+	if self.updateB {
+		c.Policy.Params["next_table"] = 1.0
+	}
+	return WriteCheckpoint(filename, c)
+}
+
+// Restore the training state saved by SaveCheckpoint. Must be called after
+// Init; a subsequent call to Learn continues from the epoch following the
+// checkpoint.
+func (self *DoubleQLearning) LoadCheckpoint(filename string, env environment.Environment) error {
+	c, err := self.loadTableCheckpoint(filename)
+	if err != nil {
+		return err
+	}
+	next, ok := c.Policy.Params["next_table"]
+	if !ok {
+		return fmt.Errorf("error loading checkpoint from '%v': no table to update next", filename)
+	}
+	self.updateB = next != 0.0
+	self.resume(c, env)
+	return nil
+}
+
+// return the index of the action with the largest value in one row of a table
+func argmax(row []float64) (indexOfBest uint) {
+	for i := 1; i < len(row); i++ {
+		if row[i] > row[indexOfBest] {
+			indexOfBest = uint(i)
+		}
+	}
+	return
+}
+
+// Learn the Q-values
+func (self *DoubleQLearning) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		self.DiscretizeState(&s)
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+
+			// select an action from the sum of the tables
+			aIndex, _, _ := self.EpsilonGreedyAction(s, self.epsilon)
+			a := self.actions[aIndex]
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, a)
+			self.DiscretizeState(&sp)
+
+			delta := self.update(s, a, sp, reward, env.AtGoalState(sp) || env.AtFailState(sp))
+
+			s = sp
+			stats.Step(reward, delta)
+		}
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// update the table whose turn it is after taking action a in state s and
+// moving to state sp with the given reward, evaluating the table's greedy
+// action in sp with the other table, and pass the turn to the other table.
+// Returns the TD error.
+func (self *DoubleQLearning) update(s space.State, a space.Action, sp space.State, reward float64, terminal bool) float64 {
+	update, other := self.tables["A"], self.tables["B"]
+	if self.updateB {
+		update, other = other, update
+	}
+	self.updateB = !self.updateB
+
+	delta := reward - update[s.Id][a.Id]
+	if !terminal {
+		delta += self.gamma * other[sp.Id][argmax(update[sp.Id])]
+	}
+	update[s.Id][a.Id] += self.alpha * delta
+	self.Q[s.Id][a.Id] = update[s.Id][a.Id] + other[s.Id][a.Id]
+	return delta
+}
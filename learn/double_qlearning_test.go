@@ -0,0 +1,76 @@
+package learn
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+func TestDoubleQLearningActsOnSum(t *testing.T) {
+	cfg, err := config.Parse(strings.NewReader(checkpointTestConfig), "test.cfg")
+	if err != nil {
+		t.Fatalf("Error parsing configuration: %v\n", err)
+	}
+	env := environment.NewCartPoleEnv(random.NewRand(1))
+	lrn := NewDoubleQLearning(random.NewRand(2))
+	if err := lrn.Init(cfg, env); err != nil {
+		t.Fatalf("Error initializing learner: %v\n", err)
+	}
+	if err := lrn.Learn(env); err != nil {
+		t.Fatalf("Error learning: %v\n", err)
+	}
+
+	a, b := lrn.tables["A"], lrn.tables["B"]
+	updated := false
+	for i := range lrn.Q {
+		for j := range lrn.Q[i] {
+			if math.Abs(lrn.Q[i][j]-(a[i][j]+b[i][j])) > 1e-12 {
+				t.Fatalf("Q[%v][%v] = %v: expected A + B = %v\n", i, j, lrn.Q[i][j], a[i][j]+b[i][j])
+			}
+			updated = updated || a[i][j] != 0 || b[i][j] != 0
+		}
+	}
+	if !updated {
+		t.Error("Neither table was updated.\n")
+	}
+}
+
+func TestDoubleQLearningAlternates(t *testing.T) {
+	lrn := NewDoubleQLearning(random.NewRand(1))
+	lrn.alpha, lrn.gamma = 0.5, 1.0
+	lrn.Q = [][]float64{{0.0, 0.0}, {0.0, 0.0}}
+	lrn.tables = map[string][][]float64{
+		"A": {{0.0, 0.0}, {1.0, 0.0}},
+		"B": {{0.0, 0.0}, {2.0, 4.0}},
+	}
+	s, sp := space.State{Id: 0}, space.State{Id: 1}
+	a := space.Action{Id: 1}
+
+	// A is updated first, towards B's value of A's greedy action in sp, then
+	// B towards A's value of B's greedy action, and then A again
+	tests := []struct {
+		table string
+		delta float64
+		value float64
+	}{
+		{"A", 2.0, 1.0},
+		{"B", 0.0, 0.0},
+		{"A", 1.0, 1.5},
+	}
+	for i, tt := range tests {
+		if delta := lrn.update(s, a, sp, 0.0, false); delta != tt.delta {
+			t.Errorf("Step %v: TD error = %v, expected %v\n", i+1, delta, tt.delta)
+		}
+		if v := lrn.tables[tt.table][s.Id][a.Id]; v != tt.value {
+			t.Errorf("Step %v: %v[s][a] = %v, expected %v\n", i+1, tt.table, v, tt.value)
+		}
+		if sum := lrn.tables["A"][s.Id][a.Id] + lrn.tables["B"][s.Id][a.Id]; lrn.Q[s.Id][a.Id] != sum {
+			t.Errorf("Step %v: Q[s][a] = %v, expected A + B = %v\n", i+1, lrn.Q[s.Id][a.Id], sum)
+		}
+	}
+}
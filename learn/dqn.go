@@ -0,0 +1,376 @@
+package learn
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/nn"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// DQN (Mnih et al., 2015): Q-learning with a multilayer perceptron, set in
+// the [network] section, mapping the state to the value of each action. The
+// network's inputs are State.Vals, each scaled from its range in Features()
+// to [-1, 1]. Each step is stored in a replay buffer of the last replay_size
+// transitions, and once replay_start of them have been collected, every step
+// also draws a batch of batch_size transitions from the buffer, and takes a
+// step of Adam along the gradient of their mean Huber loss (quadratic within
+// one of the target, linear beyond). The targets bootstrap from a copy of
+// the network that is only updated every target_interval steps, which keeps
+// them from chasing the network's own updates. Neither alpha nor lambda is
+// used.
+type DQN struct {
+	episodic
+	actions        []space.Action
+	ranges         []space.Range
+	net, target    *nn.MLP
+	opt            *nn.Adam
+	replay         *replayBuffer
+	gamma          float64
+	batchSize      int
+	replayStart    int
+	targetInterval uint
+	steps          uint // steps taken over all episodes, by which the target is updated
+
+	batch []Experience
+	grad  []float64
+}
+
+// the training state of a DQN learner saved with its checkpoints, besides
+// the network
+type dqnState struct {
+	Target     []nn.Layer
+	Optimizer  nn.Adam
+	Replay     []Experience
+	ReplayNext int
+	Steps      uint
+}
+
+// return a DQN learner drawing random numbers from rng
+func NewDQN(rng *random.Rand) *DQN {
+	return &DQN{episodic: episodic{name: "dqn", rng: rng}}
+}
+
+// Build the action space and the networks, and read the learning
+// parameters.
+func (self *DQN) Init(cfg *config.Config, env environment.Environment) (err error) {
+	if err = self.initEpisodes(cfg); err != nil {
+		return
+	}
+	if self.actions, err = space.BuildActionSpace(cfg, env.ActionRange()); err != nil {
+		return
+	}
+	self.ranges = env.Features()
+	if self.gamma, err = cfg.Float64("learning", "gamma"); err != nil {
+		return
+	}
+
+	hidden, err := cfg.IntArray("network", "hidden")
+	if err != nil {
+		return
+	}
+	act, err := cfg.String("network", "activation")
+	if err != nil {
+		return
+	}
+	sizes := append(append([]int{len(self.ranges)}, hidden...), len(self.actions))
+	if self.net, err = nn.NewMLP(sizes, act, self.rng); err != nil {
+		return &config.ParamError{Section: "network", Key: "hidden", Err: err}
+	}
+	self.target = self.net.Clone()
+	rate, err := cfg.Float64("network", "learning_rate")
+	if err != nil {
+		return
+	}
+	self.opt = nn.NewAdam(self.net, rate)
+
+	replaySize, err := cfg.Uint("learning", "replay_size")
+	if err != nil {
+		return
+	}
+	batchSize, err := cfg.Uint("learning", "batch_size")
+	if err != nil {
+		return
+	}
+	replayStart, err := cfg.Uint("learning", "replay_start")
+	if err != nil {
+		return
+	}
+	if replaySize < 1 || batchSize < 1 {
+		return &config.ParamError{Section: "learning", Key: "batch_size",
+			Err: fmt.Errorf("replay_size and batch_size must be at least one")}
+	}
+	if replayStart > replaySize {
+		// the buffer would never hold enough transitions to start learning
+		return &config.ParamError{Section: "learning", Key: "replay_start",
+			Err: fmt.Errorf("replay_start (%v) must not exceed replay_size (%v)", replayStart, replaySize)}
+	}
+	if self.targetInterval, err = cfg.Uint("learning", "target_interval"); err != nil {
+		return
+	}
+	if self.targetInterval < 1 {
+		return &config.ParamError{Section: "learning", Key: "target_interval",
+			Err: fmt.Errorf("target_interval must be at least one")}
+	}
+	self.replay = newReplayBuffer(int(replaySize))
+	self.batchSize, self.replayStart = int(batchSize), int(replayStart)
+	self.steps = 0
+	self.grad = make([]float64, len(self.actions))
+	return nil
+}
+
+// return the inputs of the network for state s
+func (self *DQN) inputs(s space.State) []float64 {
+	x := make([]float64, len(self.ranges))
+	for i, r := range self.ranges {
+		x[i] = 2.0*(s.Vals[i]-r.Min)/(r.Max-r.Min) - 1.0
+	}
+	return x
+}
+
+// Return the index of the best action from a given state
+func (self *DQN) ArgmaxAction(s space.State) (indexOfBest uint, valueOfBest float64) {
+	q := self.net.Forward(self.inputs(s))
+	indexOfBest = argmax(q)
+	return indexOfBest, q[indexOfBest]
+}
+
+// Return a random action and its estimated value
+func (self *DQN) RandomAction(s space.State) (indexOfBest uint, valueOfBest float64) {
+	indexOfBest = uint(self.rng.Intn(len(self.actions)))
+	valueOfBest = self.net.Forward(self.inputs(s))[indexOfBest]
+	return
+}
+
+// Return an epsilon-greedy action, its estimated value, and whether it was chosen greedily
+func (self *DQN) EpsilonGreedyAction(s space.State, epsilon float64) (indexOfBest uint, valueOfBest float64, wasGreedy bool) {
+	if self.rng.Float64() < epsilon {
+		indexOfBest, valueOfBest = self.RandomAction(s)
+		return indexOfBest, valueOfBest, false
+	}
+	indexOfBest, valueOfBest = self.ArgmaxAction(s)
+	return indexOfBest, valueOfBest, true
+}
+
+// Return the set of actions the learner chooses from
+func (self *DQN) Actions() []space.Action {
+	return self.actions
+}
+
+// Return the greedy action for an arbitrary continuous state
+func (self *DQN) GreedyAction(s space.State) space.Action {
+	aIndex, _ := self.ArgmaxAction(s)
+	return self.actions[aIndex]
+}
+
+func (self *DQN) FollowPolicy(env environment.Environment) {
+	self.follow(env, self.GreedyAction)
+}
+
+// return the parameters of a network's layers as saved in a policy: the
+// weights and biases of layer k as Wk and bk, from one
+func layerWeights(layers []nn.Layer) map[string][][]float64 {
+	weights := make(map[string][][]float64)
+	for k, l := range layers {
+		weights[fmt.Sprintf("W%v", k+1)] = l.W
+		weights[fmt.Sprintf("b%v", k+1)] = [][]float64{l.B}
+	}
+	return weights
+}
+
+// return the layers saved in a policy by layerWeights
+func weightLayers(weights map[string][][]float64) ([]nn.Layer, error) {
+	var layers []nn.Layer
+	for k := 1; ; k++ {
+		w, okW := weights[fmt.Sprintf("W%v", k)]
+		b, okB := weights[fmt.Sprintf("b%v", k)]
+		if !okW && !okB {
+			break
+		}
+		if !okW || !okB || len(b) != 1 {
+			return nil, fmt.Errorf("layer %v has no weights or no biases", k)
+		}
+		layers = append(layers, nn.Layer{W: w, B: b[0]})
+	}
+	if len(weights) != 2*len(layers) {
+		return nil, fmt.Errorf("policy has weights other than those of a network's layers")
+	}
+	return layers, nil
+}
+
+// replace the actions and network with those of a saved policy, which must
+// have the same shape as the configured network
+func (self *DQN) loadNetwork(p *Policy) error {
+	layers, err := weightLayers(p.Weights)
+	if err == nil {
+		err = self.net.Load(layers)
+	}
+	if err != nil {
+		return fmt.Errorf("policy does not match the network: %v", err)
+	}
+	if len(p.Actions) != len(self.actions) {
+		return fmt.Errorf("policy has %v actions, not %v", len(p.Actions), len(self.actions))
+	}
+	self.actions = p.Actions
+	self.target.CopyFrom(self.net)
+	return nil
+}
+
+// Save the actions and network to a file
+func (self *DQN) SavePolicy(filename string) error {
+	p := &Policy{
+		Learner: self.name,
+		Actions: self.actions,
+		Weights: layerWeights(self.net.Layers),
+		Params: map[string]float64{
+			"gamma":         self.gamma,
+			"epsilon":       self.epsilon,
+			"learning_rate": self.opt.Rate,
+		},
+	}
+	return WritePolicy(filename, p)
+}
+
+// Replace the actions and network with those from a saved policy, and copy
+// the network to the target. Must be called after Init, with the same
+// network as the policy was learned with; the learning parameters from the
+// configuration file are left untouched.
+func (self *DQN) LoadPolicy(filename string) error {
+	p, err := ReadPolicy(filename)
+	if err != nil {
+		return err
+	}
+	if err = self.loadNetwork(p); err != nil {
+		return fmt.Errorf("error loading policy from '%v': %v", filename, err)
+	}
+	return nil
+}
+
+// Save the complete training state, including the target network, the
+// optimizer, and the replay buffer, so that learning can be resumed later
+func (self *DQN) SaveCheckpoint(filename string, env environment.Environment) (err error) {
+	c := self.checkpoint(env)
+	c.Policy.Actions, c.Policy.Weights = self.actions, layerWeights(self.net.Layers)
+	st := dqnState{Target: self.target.Layers, Optimizer: *self.opt,
+		Replay: self.replay.items, ReplayNext: self.replay.next, Steps: self.steps}
+	if c.Extra, err = json.Marshal(st); err != nil {
+		return
+	}
+	return WriteCheckpoint(filename, c)
+}
+
+// Restore the training state saved by SaveCheckpoint. Must be called after
+// Init; a subsequent call to Learn continues from the epoch following the
+// checkpoint.
+func (self *DQN) LoadCheckpoint(filename string, env environment.Environment) error {
+	c, err := self.readCheckpoint(filename)
+	if err != nil {
+		return err
+	}
+	var st dqnState
+	if err = self.loadNetwork(&c.Policy); err == nil {
+		err = json.Unmarshal(c.Extra, &st)
+	}
+	if err == nil {
+		err = self.target.Load(st.Target)
+	}
+	if err == nil {
+		err = self.opt.Load(st.Optimizer.T, st.Optimizer.M, st.Optimizer.V)
+	}
+	if err == nil {
+		err = self.replay.restore(st.Replay, st.ReplayNext, len(self.ranges), len(self.actions))
+	}
+	if err != nil {
+		return fmt.Errorf("error loading checkpoint from '%v': %v", filename, err)
+	}
+	self.steps = st.Steps
+	self.resume(c, env)
+	return nil
+}
+
+// return the target of a transition: its reward, plus the discounted greedy
+// value of the next state under the target network unless it is terminal
+func (self *DQN) targetValue(e *Experience) float64 {
+	y := e.Reward
+	if !e.Terminal {
+		q := self.target.Forward(e.Next)
+		y += self.gamma * q[argmax(q)]
+	}
+	return y
+}
+
+// take a step of the optimizer along the gradient of the mean Huber loss
+// of a batch drawn from the replay buffer
+func (self *DQN) train() {
+	self.batch = self.replay.sample(self.rng, self.batchSize, self.batch)
+	for i := range self.batch {
+		e := &self.batch[i]
+		y := self.targetValue(e)
+		q := self.net.Forward(e.S)
+
+		// the gradient of the Huber loss is the error, clipped to [-1, 1]
+		for a := range self.grad {
+			self.grad[a] = 0.0
+		}
+		self.grad[e.A] = math.Max(-1.0, math.Min(1.0, q[e.A]-y))
+		self.net.Backward(self.grad)
+	}
+	self.opt.Step(self.net, 1.0/float64(len(self.batch)))
+}
+
+// Learn the network
+func (self *DQN) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+
+			// select an action
+			aIndex, qA, _ := self.EpsilonGreedyAction(s, self.epsilon)
+
+			// observe reward, next state, and store the transition. It is
+			// replayed long after the episode, so running out of time is not
+			// stored as reaching a terminal state.
+			sp, reward := env.ApplyAction(s, self.actions[aIndex])
+			e := Experience{S: self.inputs(s), A: aIndex, Reward: reward, Next: self.inputs(sp),
+				Terminal: environment.Terminal(env, sp)}
+			self.replay.add(e)
+			delta := self.targetValue(&e) - qA
+
+			// learn from replayed experience, and periodically update the
+			// target network
+			if len(self.replay.items) >= self.replayStart {
+				self.train()
+			}
+			self.steps++
+			if self.steps%self.targetInterval == 0 {
+				self.target.CopyFrom(self.net)
+			}
+
+			s = sp
+			stats.Step(reward, delta)
+		}
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
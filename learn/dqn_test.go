@@ -0,0 +1,103 @@
+package learn
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/random"
+)
+
+func TestReplayBuffer(t *testing.T) {
+	b := newReplayBuffer(3)
+	for i := 0; i < 5; i++ {
+		b.add(Experience{A: uint(i)})
+	}
+
+	// the two oldest experiences have been replaced
+	for i, expected := range []uint{3, 4, 2} {
+		if b.items[i].A != expected {
+			t.Errorf("Slot %v holds experience %v, expected %v\n", i, b.items[i].A, expected)
+		}
+	}
+	if b.next != 2 {
+		t.Errorf("Next slot %v, expected 2\n", b.next)
+	}
+	batch := b.sample(random.NewRand(1), 10, nil)
+	for _, e := range batch {
+		if e.A < 2 {
+			t.Errorf("Sampled experience %v, which was replaced\n", e.A)
+		}
+	}
+
+	// a saved buffer must fit the capacity
+	if err := b.restore(b.items, 3, 0, 5); err == nil {
+		t.Errorf("Expected an error restoring a buffer with next slot 3\n")
+	}
+}
+
+func TestDQNReplayStart(t *testing.T) {
+	env := environment.NewCartPoleEnv(random.NewRand(1))
+	tests := []struct {
+		start string
+		ok    bool
+	}{
+		{"100", true},
+		{"101", false},
+	}
+	for _, tt := range tests {
+		cfg := config.New()
+		cfg.Set("network", "hidden", "8")
+		cfg.Set("learning", "replay_size", "100")
+		cfg.Set("learning", "replay_start", tt.start)
+		err := NewDQN(random.NewRand(1)).Init(cfg, env)
+		var pe *config.ParamError
+		if tt.ok && err != nil {
+			t.Errorf("Unexpected error with replay_start = %v: %v\n", tt.start, err)
+		} else if !tt.ok && (!errors.As(err, &pe) || pe.Key != "replay_start") {
+			t.Errorf("Expected an error on replay_start = %v above replay_size, got %v\n", tt.start, err)
+		}
+	}
+}
+
+func TestDQNUpdate(t *testing.T) {
+	cfg := config.New()
+	cfg.Set("network", "hidden", "8")
+	cfg.Set("learning", "gamma", "0.5")
+	cfg.Set("learning", "replay_size", "1")
+	cfg.Set("learning", "replay_start", "1")
+	cfg.Set("learning", "batch_size", "1")
+	lrn := NewDQN(random.NewRand(1))
+	if err := lrn.Init(cfg, environment.NewCartPoleEnv(random.NewRand(1))); err != nil {
+		t.Fatalf("Error initializing learner: %v\n", err)
+	}
+	e := Experience{S: []float64{0.5, -0.5, 0.25, 0.0}, A: 1, Reward: 1.0, Next: []float64{-0.5, 0.5, 0.0, 0.25}}
+
+	// a terminal transition's target is its reward, and any other's
+	// bootstraps from the target network's greedy value of the next state
+	terminal := e
+	terminal.Terminal = true
+	if y := lrn.targetValue(&terminal); y != 1.0 {
+		t.Errorf("Target of a terminal transition = %v, expected its reward 1\n", y)
+	}
+	q := append([]float64(nil), lrn.target.Forward(e.Next)...)
+	expected := 1.0 + 0.5*q[argmax(q)]
+	if y := lrn.targetValue(&e); y != expected {
+		t.Errorf("Target = %v, expected %v\n", y, expected)
+	}
+
+	// a step of training moves the network's value of the transition's action
+	// towards the target, and leaves the target network, and so the target,
+	// alone until it is next copied
+	lrn.replay.add(e)
+	before := math.Abs(lrn.net.Forward(e.S)[e.A] - expected)
+	lrn.train()
+	if after := math.Abs(lrn.net.Forward(e.S)[e.A] - expected); after >= before {
+		t.Errorf("Error of the updated action = %v after training, expected less than %v\n", after, before)
+	}
+	if y := lrn.targetValue(&e); y != expected {
+		t.Errorf("Target after training = %v, expected it unchanged at %v\n", y, expected)
+	}
+}
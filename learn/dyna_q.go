@@ -0,0 +1,134 @@
+package learn
+
+import (
+	"fmt"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+)
+
+// Dyna-Q (Sutton and Barto, 2018, section 8.2): one-step Q-learning from real
+// experience, which is also recorded in a tabular model of the environment.
+// After each real step, planning_steps further updates are made from
+// transitions drawn at random from the model, trading computation for
+// experience. Lambda is not used.
+type DynaQ struct {
+	tabular
+	planningSteps uint
+	model         *model
+}
+
+// return a Dyna-Q learner drawing random numbers from rng
+func NewDynaQ(rng *random.Rand) *DynaQ {
+	return &DynaQ{tabular: tabular{episodic: episodic{name: "dyna_q", rng: rng}}}
+}
+
+// Initialize the Q-values table and an empty model.
+func (self *DynaQ) Init(cfg *config.Config, env environment.Environment) (err error) {
+	if err = self.init(cfg, env); err != nil {
+		return
+	}
+	if self.planningSteps, err = cfg.Uint("learning", "planning_steps"); err != nil {
+		return
+	}
+	self.model = newModel()
+	return nil
+}
+
+// Replace the state lattice, actions, and Q-values with those from a saved
+// policy, and start an empty model, as the old one would refer to the
+// previous lattice. Must be called after Init.
+func (self *DynaQ) LoadPolicy(filename string) error {
+	if err := self.tabular.LoadPolicy(filename); err != nil {
+		return err
+	}
+	self.model = newModel()
+	return nil
+}
+
+// Save the complete training state, including the model, so that learning
+// can be resumed later
+func (self *DynaQ) SaveCheckpoint(filename string, env environment.Environment) error {
+	c := self.tableCheckpoint(env)
+	c.Model = self.model.transitions
+	return WriteCheckpoint(filename, c)
+}
+
+// Restore the training state saved by SaveCheckpoint. Must be called after
+// Init; a subsequent call to Learn continues from the epoch following the
+// checkpoint.
+func (self *DynaQ) LoadCheckpoint(filename string, env environment.Environment) error {
+	c, err := self.loadModelCheckpoint(filename)
+	if err != nil {
+		return err
+	}
+	self.resume(c, env)
+	return nil
+}
+
+// restore the tables, trace, and model from a checkpoint, returning it
+func (self *DynaQ) loadModelCheckpoint(filename string) (*Checkpoint, error) {
+	c, err := self.loadTableCheckpoint(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err = self.model.restore(c.Model, len(self.states), len(self.actions)); err != nil {
+		return nil, fmt.Errorf("error loading checkpoint from '%v': %v", filename, err)
+	}
+	return c, nil
+}
+
+// Learn the Q-values
+func (self *DynaQ) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		self.DiscretizeState(&s)
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+
+			// select an action
+			aIndex, _, _ := self.EpsilonGreedyAction(s, self.epsilon)
+			a := self.actions[aIndex]
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, a)
+			self.DiscretizeState(&sp)
+
+			// learn from the real step, and record it in the model. The
+			// model keeps the transition for good, so running out of time,
+			// which depends on the episode rather than the pair, is not
+			// recorded as reaching a terminal state.
+			t := Transition{S: s.Id, A: a.Id, Next: sp.Id, Reward: reward,
+				Terminal: environment.Terminal(env, sp)}
+			delta := self.qUpdate(t)
+			self.model.observe(t)
+
+			// then plan with simulated steps
+			for n := uint(0); n < self.planningSteps; n++ {
+				self.qUpdate(self.model.sample(self.rng))
+			}
+
+			s = sp
+			stats.Step(reward, delta)
+		}
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package learn
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// The state shared by the learners that train for a number of episodes,
+// whatever they learn: the schedule of epochs, the exploration rate, and the
+// settings of their checkpoints. Learners embed an episodic, usually through
+// a tabular.
+type episodic struct {
+	name      string // the learner's name in policy and checkpoint files
+	maxEpochs uint
+	epsilon   float64
+	epoch     uint
+	maxSteps  uint
+	rng       *random.Rand
+	cfg       *config.Config
+
+	checkpointInterval uint
+	checkpointFile     string
+}
+
+// a learner that can report its progress and save checkpoints
+type checkpointingAgent interface {
+	metrics.Agent
+	Checkpointer
+}
+
+// read the training schedule and checkpoint settings
+func (self *episodic) initEpisodes(cfg *config.Config) (err error) {
+	self.cfg = cfg
+	if self.maxEpochs, err = cfg.Uint("learning", "epochs"); err != nil {
+		return
+	}
+	if self.epsilon, err = cfg.Float64("learning", "epsilon"); err != nil {
+		return
+	}
+
+	self.epoch = 0
+
+	// episodes are cut off after max_steps steps, unless it is zero
+	if self.maxSteps, err = cfg.Uint("environment", "max_steps"); err != nil {
+		return
+	}
+
+	// periodic checkpoints are optional
+	if self.checkpointInterval, err = cfg.Uint("learning", "checkpoint_interval"); err != nil {
+		return
+	}
+	if self.checkpointFile, err = cfg.String("learning", "checkpoint_file"); err != nil {
+		return
+	}
+	return nil
+}
+
+// check whether an episode has ended in state s after the given number of
+// steps, and how
+func (self *episodic) episodeOver(env environment.Environment, s space.State, steps uint) (bool, metrics.Outcome) {
+	if env.AtGoalState(s) {
+		return true, metrics.Goal
+	} else if env.AtFailState(s) {
+		return true, metrics.Fail
+	} else if self.maxSteps > 0 && steps >= self.maxSteps {
+		return true, metrics.Timeout
+	}
+	return false, metrics.Timeout
+}
+
+// play an episode following policy, printing each step, until a goal or fail
+// state or the step limit for episodes run outside of training, as an
+// untrained policy may never reach either
+func (self *episodic) follow(env environment.Environment, policy func(s space.State) space.Action) {
+	metrics.RunEpisode(env, policy, metrics.EpisodeLimit(self.cfg), os.Stdout)
+}
+
+// finish a training epoch of lrn: record it, decay the exploration rate, and
+// save a checkpoint if one is due
+func (self *episodic) endEpoch(epoch uint, stats *metrics.EpisodeStats, outcome metrics.Outcome,
+	env environment.Environment, monitor *metrics.Monitor, lrn checkpointingAgent) error {
+	rec := stats.Record(epoch, outcome, self.epsilon)
+	self.epsilon *= 0.95
+	self.epoch = epoch
+	if err := monitor.EndEpoch(rec, env, lrn); err != nil {
+		return err
+	}
+	if self.checkpointInterval > 0 && epoch%self.checkpointInterval == 0 {
+		return lrn.SaveCheckpoint(self.checkpointFile, env)
+	}
+	return nil
+}
+
+// start a checkpoint of the training schedule and the generators, to which
+// the learner adds its policy and any further state
+func (self *episodic) checkpoint(env environment.Environment) *Checkpoint {
+	c := &Checkpoint{
+		Epoch:     self.epoch,
+		Epsilon:   self.epsilon,
+		RandState: self.rng.State(),
+	}
+	c.Policy.Learner = self.name
+	if st, ok := env.(environment.Stochastic); ok {
+		c.EnvRandState = st.Rng().State()
+	}
+	return c
+}
+
+// read a checkpoint saved by a learner of the same kind
+func (self *episodic) readCheckpoint(filename string) (*Checkpoint, error) {
+	c, err := ReadCheckpoint(filename)
+	if err != nil {
+		return nil, err
+	}
+	if c.Policy.Learner != self.name {
+		return nil, fmt.Errorf("error loading checkpoint from '%v': saved by learner '%v', not '%v'",
+			filename, c.Policy.Learner, self.name)
+	}
+	return c, nil
+}
+
+// restore the training schedule and the generators from a checkpoint
+func (self *episodic) resume(c *Checkpoint, env environment.Environment) {
+	self.epoch, self.epsilon = c.Epoch, c.Epsilon
+	self.rng.SetState(c.RandState)
+	if st, ok := env.(environment.Stochastic); ok {
+		st.Rng().SetState(c.EnvRandState)
+	}
+}
@@ -0,0 +1,91 @@
+package learn
+
+import (
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// Expected Sarsa(lambda): bootstraps from the expected value of the next
+// state under the epsilon-greedy policy, rather than from the value of the
+// one action sampled from it (as Sarsa does) or of the greedy action (as
+// Q-learning does), which removes the variance due to the choice of action
+type ExpectedSarsa struct {
+	tabular
+}
+
+// return an Expected Sarsa learner drawing random numbers from rng
+func NewExpectedSarsa(rng *random.Rand) *ExpectedSarsa {
+	return &ExpectedSarsa{tabular{episodic: episodic{name: "expected_sarsa", rng: rng}}}
+}
+
+// Initialize the Q-values table and trace.
+func (self *ExpectedSarsa) Init(cfg *config.Config, env environment.Environment) error {
+	return self.init(cfg, env)
+}
+
+// Return the expected value of a state when acting epsilon-greedily: the
+// greedy action is taken with probability 1 - epsilon, and each action
+// (including the greedy one) with probability epsilon / |A|.
+func (self *ExpectedSarsa) ExpectedValue(s space.State, epsilon float64) float64 {
+	_, valueOfBest := self.ArgmaxAction(s)
+	mean := 0.0
+	for _, q := range self.Q[s.Id] {
+		mean += q
+	}
+	mean /= float64(len(self.Q[s.Id]))
+	return (1.0-epsilon)*valueOfBest + epsilon*mean
+}
+
+// Learn the Q-values
+func (self *ExpectedSarsa) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		self.DiscretizeState(&s)
+		self.clearTrace()
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+
+			// select an action
+			aIndex, _, _ := self.EpsilonGreedyAction(s, self.epsilon)
+			a := self.actions[aIndex]
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, a)
+			self.DiscretizeState(&sp)
+
+			// bootstrap from the expected value of the next state unless the
+			// episode has ended
+			delta := reward - self.Q[s.Id][a.Id]
+			if !env.AtGoalState(sp) && !env.AtFailState(sp) {
+				delta += self.gamma * self.ExpectedValue(sp, self.epsilon)
+			}
+
+			// mark the visited pair in the trace and update the policy
+			self.visit(s, a)
+			self.traceUpdate(delta)
+
+			s = sp
+			stats.Step(reward, delta)
+		}
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package learn
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+func TestExpectedValue(t *testing.T) {
+	lrn := NewExpectedSarsa(random.NewRand(1))
+	lrn.Q = [][]float64{{1.0, 4.0, -2.0}}
+	s := space.State{Id: 0}
+	tests := []struct{ epsilon, expected float64 }{
+		{0.0, 4.0},               // greedy
+		{1.0, 1.0},               // uniformly random
+		{0.3, 0.7*4.0 + 0.3*1.0}, // greedy with probability 0.7 + 0.3/3
+	}
+	for _, et := range tests {
+		if v := lrn.ExpectedValue(s, et.epsilon); math.Abs(v-et.expected) > 1e-12 {
+			t.Errorf("ExpectedValue with epsilon %v = %v, expected %v\n", et.epsilon, v, et.expected)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package learn implements the reinforcement learning algorithms, and the
+// policy and checkpoint files they read and write.
+package learn
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+type Learner interface {
+	Init(cfg *config.Config, env environment.Environment) error
+	Learn(env environment.Environment) error
+	ArgmaxAction(s space.State) (indexOfBest uint, valueOfBest float64)
+	RandomAction(s space.State) (indexOfBest uint, valueOfBest float64)
+	EpsilonGreedyAction(s space.State, epsilon float64) (indexOfBest uint, valueOfBest float64, wasGreedy bool)
+	Actions() []space.Action
+	GreedyAction(s space.State) space.Action
+	FollowPolicy(env environment.Environment)
+	SavePolicy(filename string) error
+	LoadPolicy(filename string) error
+}
+
+// Learners with a stochastic policy, which can be followed either by sampling
+// from it or by taking its mode, as set by the follow parameter
+type Sampler interface {
+	// the action the policy takes in state s when it is followed
+	FollowAction(s space.State) space.Action
+}
+
+// return the policy of a learner as it is followed outside of training (e.g.,
+// by gorl run): sampled from, if the learner is a Sampler set to follow =
+// sample, and greedy otherwise
+func Follow(lrn Learner) func(s space.State) space.Action {
+	if sampler, ok := lrn.(Sampler); ok {
+		return sampler.FollowAction
+	}
+	return lrn.GreedyAction
+}
+
+// Constructors for the learners, keyed by the name used for the learner
+// parameter in the [learning] section
+var learners = map[string]func(rng *random.Rand) Learner{
+	"actor_critic":           func(rng *random.Rand) Learner { return NewActorCritic(rng) },
+	"actor_critic_lambda":    func(rng *random.Rand) Learner { return NewActorCriticLambda(rng) },
+	"dqn":                    func(rng *random.Rand) Learner { return NewDQN(rng) },
+	"cacla":                  func(rng *random.Rand) Learner { return NewCACLA(rng) },
+	"double_qlearning":       func(rng *random.Rand) Learner { return NewDoubleQLearning(rng) },
+	"dyna_q":                 func(rng *random.Rand) Learner { return NewDynaQ(rng) },
+	"expected_sarsa":         func(rng *random.Rand) Learner { return NewExpectedSarsa(rng) },
+	"linear_qlearning":       func(rng *random.Rand) Learner { return NewLinearQLearning(rng) },
+	"linear_sarsa":           func(rng *random.Rand) Learner { return NewLinearSarsa(rng) },
+	"monte_carlo":            func(rng *random.Rand) Learner { return NewMonteCarlo(rng) },
+	"off_policy_monte_carlo": func(rng *random.Rand) Learner { return NewOffPolicyMonteCarlo(rng) },
+	"prioritized_sweeping":   func(rng *random.Rand) Learner { return NewPrioritizedSweeping(rng) },
+	"qlearning":              func(rng *random.Rand) Learner { return NewQLearning(rng) },
+	"reinforce":              func(rng *random.Rand) Learner { return NewReinforce(rng) },
+	"reinforce_baseline":     func(rng *random.Rand) Learner { return NewReinforceBaseline(rng) },
+	"rlearning":              func(rng *random.Rand) Learner { return NewRLearning(rng) },
+	"sarsa":                  func(rng *random.Rand) Learner { return NewSarsa(rng) },
+}
+
+// make a learner available to configurations under the given name, replacing
+// any registered before under that name. Register is meant to be called from
+// init functions, before any learner is created.
+func Register(name string, create func(rng *random.Rand) Learner) {
+	learners[name] = create
+}
+
+// return a new learner of the named kind drawing random numbers from rng, and
+// whether the name is known
+func New(name string, rng *random.Rand) (Learner, bool) {
+	create, ok := learners[name]
+	if !ok {
+		return nil, false
+	}
+	return create(rng), true
+}
+
+// return the names of the known learners, in sorted order
+func Names() []string {
+	names := make([]string, 0, len(learners))
+	for name := range learners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// return a new learner drawing random numbers from rng. The learner must be
+// initialized with Init before use.
+func Create(cfg *config.Config, rng *random.Rand) (Learner, error) {
+	name, err := cfg.String("learning", "learner")
+	if err != nil {
+		return nil, err
+	}
+	lrn, ok := New(name, rng)
+	if !ok {
+		return nil, &config.ParamError{Section: "learning", Key: "learner",
+			Err: fmt.Errorf("unknown learner '%v' (expected one of %v)", name, Names())}
+	}
+	return lrn, nil
+}
+
+// close c when a function returns, reporting the error from Close if the
+// function had not already failed
+func closeOnReturn(c io.Closer, err *error) {
+	if cerr := c.Close(); *err == nil {
+		*err = cerr
+	}
+}
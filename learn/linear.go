@@ -0,0 +1,299 @@
+package learn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/features"
+	"github.com/deong/gorl/space"
+)
+
+// The state shared by the learners that approximate action values as linear
+// functions of the features of the state, as built from the [features]
+// section, rather than keeping a table over a lattice: the value of an
+// action is the dot product of its weights with the features. The weights
+// are updated along the gradient of the value, which is the features
+// themselves, so that a learner over one-hot features of the lattice states
+// would be tabular. The eligibility trace has an entry per weight, and the
+// trace and learning parameters are those of tabular.
+type linear struct {
+	episodic
+	actions   []space.Action
+	extractor features.Extractor
+	w         [][]float64 // the weights of each action's value, by action and then feature
+	e         [][]float64 // the trace of each weight
+	active    []pair      // the weights whose trace is nonzero, by feature (as s) and action
+	alpha     float64
+	gamma     float64
+	lambda    float64
+	trace     string
+	threshold float64
+
+	x features.Vector // the features of the state last asked about
+	q []float64       // the action values in that state
+}
+
+// Build the action space and feature extractor, initialize the weights and
+// trace, and read the learning parameters.
+func (self *linear) init(cfg *config.Config, env environment.Environment) (err error) {
+	if err = self.initEpisodes(cfg); err != nil {
+		return
+	}
+	if self.actions, err = space.BuildActionSpace(cfg, env.ActionRange()); err != nil {
+		return
+	}
+	if self.extractor, err = features.New(cfg, env.Features()); err != nil {
+		return
+	}
+	self.w = self.newWeights()
+	self.e = self.newWeights()
+	self.q = make([]float64, len(self.actions))
+
+	if self.alpha, err = cfg.Float64("learning", "alpha"); err != nil {
+		return
+	}
+	if self.gamma, err = cfg.Float64("learning", "gamma"); err != nil {
+		return
+	}
+	if self.lambda, err = cfg.Float64("learning", "lambda"); err != nil {
+		return
+	}
+	if self.trace, err = cfg.String("learning", "trace"); err != nil {
+		return
+	}
+	if self.trace != "accumulating" && self.trace != "replacing" && self.trace != "dutch" {
+		return &config.ParamError{Section: "learning", Key: "trace",
+			Err: fmt.Errorf("unknown trace '%v' (expected accumulating, replacing, or dutch)", self.trace)}
+	}
+	if self.threshold, err = cfg.Float64("learning", "trace_threshold"); err != nil {
+		return
+	}
+	self.active = nil
+	return nil
+}
+
+// return zeroed weights, one per action and feature
+func (self *linear) newWeights() [][]float64 {
+	w := make([][]float64, len(self.actions))
+	for i := range w {
+		w[i] = make([]float64, self.extractor.Len())
+	}
+	return w
+}
+
+// return the estimated value of action a in a state with features x
+func (self *linear) value(x *features.Vector, a uint) float64 {
+	return x.Dot(self.w[a])
+}
+
+// return the greedy action in a state with features x, and its value
+func (self *linear) argmax(x *features.Vector) (indexOfBest uint, valueOfBest float64) {
+	for a := range self.w {
+		self.q[a] = self.value(x, uint(a))
+	}
+	indexOfBest, valueOfBest = 0, self.q[0]
+	for i := 1; i < len(self.q); i++ {
+		if self.q[i] > valueOfBest {
+			indexOfBest, valueOfBest = uint(i), self.q[i]
+		}
+	}
+	return
+}
+
+// return an epsilon-greedy action in a state with features x, its value, and
+// whether it was chosen greedily
+func (self *linear) epsilonGreedy(x *features.Vector, epsilon float64) (indexOfBest uint, valueOfBest float64, wasGreedy bool) {
+	if self.rng.Float64() < epsilon {
+		indexOfBest = uint(self.rng.Intn(len(self.actions)))
+		return indexOfBest, self.value(x, indexOfBest), false
+	}
+	indexOfBest, valueOfBest = self.argmax(x)
+	return indexOfBest, valueOfBest, true
+}
+
+// Return the index of the best action from a given state
+func (self *linear) ArgmaxAction(s space.State) (indexOfBest uint, valueOfBest float64) {
+	self.extractor.Extract(s.Vals, &self.x)
+	return self.argmax(&self.x)
+}
+
+// Return a random action and its estimated value
+func (self *linear) RandomAction(s space.State) (indexOfBest uint, valueOfBest float64) {
+	self.extractor.Extract(s.Vals, &self.x)
+	indexOfBest = uint(self.rng.Intn(len(self.actions)))
+	return indexOfBest, self.value(&self.x, indexOfBest)
+}
+
+// Return an epsilon-greedy action, its estimated value, and whether it was chosen greedily
+func (self *linear) EpsilonGreedyAction(s space.State, epsilon float64) (indexOfBest uint, valueOfBest float64, wasGreedy bool) {
+	self.extractor.Extract(s.Vals, &self.x)
+	return self.epsilonGreedy(&self.x, epsilon)
+}
+
+// Return the set of actions the learner chooses from
+func (self *linear) Actions() []space.Action {
+	return self.actions
+}
+
+// Return the greedy action for an arbitrary continuous state
+func (self *linear) GreedyAction(s space.State) space.Action {
+	aIndex, _ := self.ArgmaxAction(s)
+	return self.actions[aIndex]
+}
+
+func (self *linear) FollowPolicy(env environment.Environment) {
+	self.follow(env, self.GreedyAction)
+}
+
+// replace the actions and weights with those of a saved policy, which must
+// have been learned over the same features
+func (self *linear) loadWeights(p *Policy) error {
+	w, ok := p.Weights["w"]
+	if !ok {
+		return fmt.Errorf("policy learned by %v has no weights of action values", p.Learner)
+	}
+	if len(w) != len(p.Actions) {
+		return fmt.Errorf("policy has weights for %v actions, not %v", len(w), len(p.Actions))
+	}
+	for i := range w {
+		if len(w[i]) != self.extractor.Len() {
+			return fmt.Errorf("policy was learned over %v features, not %v", len(w[i]), self.extractor.Len())
+		}
+	}
+	self.actions, self.w = p.Actions, w
+	self.e = self.newWeights()
+	self.active = nil
+	self.q = make([]float64, len(self.actions))
+	return nil
+}
+
+// Save the actions and weights to a file
+func (self *linear) SavePolicy(filename string) error {
+	p := &Policy{
+		Learner: self.name,
+		Actions: self.actions,
+		Weights: map[string][][]float64{"w": self.w},
+		Params: map[string]float64{
+			"alpha":   self.alpha,
+			"gamma":   self.gamma,
+			"lambda":  self.lambda,
+			"epsilon": self.epsilon,
+		},
+	}
+	return WritePolicy(filename, p)
+}
+
+// Replace the actions and weights with those from a saved policy. Must be
+// called after Init, with the same features as the policy was learned over;
+// the learning parameters from the configuration file are left untouched.
+func (self *linear) LoadPolicy(filename string) error {
+	p, err := ReadPolicy(filename)
+	if err != nil {
+		return err
+	}
+	if err = self.loadWeights(p); err != nil {
+		return fmt.Errorf("error loading policy from '%v': %v", filename, err)
+	}
+	return nil
+}
+
+// Save the complete training state so that learning can be resumed later.
+// The trace is cleared at the start of each episode, so it is not saved.
+func (self *linear) SaveCheckpoint(filename string, env environment.Environment) error {
+	c := self.checkpoint(env)
+	c.Policy.Actions, c.Policy.Weights = self.actions, map[string][][]float64{"w": self.w}
+	return WriteCheckpoint(filename, c)
+}
+
+// Restore the training state saved by SaveCheckpoint. Must be called after
+// Init; a subsequent call to Learn continues from the epoch following the
+// checkpoint.
+func (self *linear) LoadCheckpoint(filename string, env environment.Environment) error {
+	c, err := self.readCheckpoint(filename)
+	if err != nil {
+		return err
+	}
+	if err = self.loadWeights(&c.Policy); err != nil {
+		return fmt.Errorf("error loading checkpoint from '%v': %v", filename, err)
+	}
+	self.resume(c, env)
+	return nil
+}
+
+// update the weights after taking action a in a state with features x
+// towards the given one-step target, marking the visit in the trace. Returns
+// the TD error.
+func (self *linear) update(x *features.Vector, a uint, target float64) float64 {
+	delta := target - self.value(x, a)
+	self.visit(x, a)
+	self.traceUpdate(delta)
+	return delta
+}
+
+// clear the trace, as at the start of an episode
+func (self *linear) clearTrace() {
+	for _, p := range self.active {
+		self.e[p.a][p.s] = 0.0
+	}
+	self.active = self.active[:0]
+}
+
+// mark a visit to action a in a state with features x in the trace. As with
+// tabular's visit, an accumulating trace adds the features to the action's
+// trace and a replacing trace sets it to them, while a dutch trace adds them
+// scaled by one less alpha times the trace's dot product with them, which
+// reduces to tabular's dutch trace for one-hot features. Unlike tabular's,
+// the trace of a weight may stay at or return to zero, as features may be zero
+// or negative, so the active set is kept to the weights whose trace is nonzero.
+func (self *linear) visit(x *features.Vector, a uint) {
+	scale := 1.0
+	if self.trace == "dutch" {
+		scale -= self.alpha * x.Dot(self.e[a])
+	}
+	for k, i := range x.Index {
+		before := self.e[a][i]
+		if self.trace == "replacing" {
+			self.e[a][i] = x.Value[k]
+		} else {
+			self.e[a][i] += scale * x.Value[k]
+		}
+		if before == 0.0 && self.e[a][i] != 0.0 {
+			self.active = append(self.active, pair{uint(i), a})
+		} else if before != 0.0 && self.e[a][i] == 0.0 {
+			self.deactivate(pair{uint(i), a})
+		}
+	}
+}
+
+// remove a weight whose trace has returned to zero from the active set
+func (self *linear) deactivate(p pair) {
+	for k := range self.active {
+		if self.active[k] == p {
+			last := len(self.active) - 1
+			self.active[k] = self.active[last]
+			self.active = self.active[:last]
+			return
+		}
+	}
+}
+
+// update every weight by alpha * delta times its trace, then decay the trace
+// by gamma * lambda, visiting only the active weights as tabular's
+// traceUpdate does
+func (self *linear) traceUpdate(delta float64) {
+	for k := 0; k < len(self.active); {
+		p := self.active[k]
+		self.w[p.a][p.s] += self.alpha * delta * self.e[p.a][p.s]
+		self.e[p.a][p.s] *= self.gamma * self.lambda
+		if math.Abs(self.e[p.a][p.s]) <= self.threshold {
+			self.e[p.a][p.s] = 0.0
+			last := len(self.active) - 1
+			self.active[k] = self.active[last]
+			self.active = self.active[:last]
+			continue
+		}
+		k++
+	}
+}
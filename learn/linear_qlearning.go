@@ -0,0 +1,103 @@
+package learn
+
+import (
+	"fmt"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/features"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+)
+
+// Watkins's Q(lambda) with linear action values over the features of the
+// state, cutting the trace whenever an exploratory action is taken. Peng's
+// variant is not available: its exchange of the visited pair's backup has no
+// counterpart when visits mark many weights at once.
+type LinearQLearning struct {
+	linear
+}
+
+// return a linear Q(lambda) learner drawing random numbers from rng
+func NewLinearQLearning(rng *random.Rand) *LinearQLearning {
+	return &LinearQLearning{linear{episodic: episodic{name: "linear_qlearning", rng: rng}}}
+}
+
+// Initialize the feature extractor, weights, and trace.
+func (self *LinearQLearning) Init(cfg *config.Config, env environment.Environment) error {
+	if err := self.init(cfg, env); err != nil {
+		return err
+	}
+	variant, err := cfg.String("learning", "q_lambda")
+	if err != nil {
+		return err
+	}
+	if variant != "watkins" {
+		return &config.ParamError{Section: "learning", Key: "q_lambda",
+			Err: fmt.Errorf("%v only supports watkins, not '%v'", self.name, variant)}
+	}
+	return nil
+}
+
+// Learn the weights
+func (self *LinearQLearning) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	var x, xp features.Vector
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		self.extractor.Extract(s.Vals, &x)
+		self.clearTrace()
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+
+			// select an action
+			aIndex, _, _ := self.epsilonGreedy(&x, self.epsilon)
+			_, valueOfS := self.argmax(&x)
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, self.actions[aIndex])
+			self.extractor.Extract(sp.Vals, &xp)
+
+			// bootstrap from the greedy value of the next state, unless the
+			// episode has ended
+			target := reward
+			if !env.AtGoalState(sp) && !env.AtFailState(sp) {
+				_, argmaxAP := self.argmax(&xp)
+				target += self.gamma * argmaxAP
+			}
+			delta := self.update(&x, aIndex, target, valueOfS)
+
+			s = sp
+			x, xp = xp, x
+			stats.Step(reward, delta)
+		}
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// update the weights after taking action a in a state with features x, whose
+// greedy value was valueOfS, towards the given one-step target. Returns the TD
+// error.
+func (self *LinearQLearning) update(x *features.Vector, a uint, target, valueOfS float64) float64 {
+	// an exploratory action ends the greedy policy's return, so the trace of
+	// the weights marked before it is cut
+	if self.value(x, a) < valueOfS {
+		self.clearTrace()
+	}
+	return self.linear.update(x, a, target)
+}
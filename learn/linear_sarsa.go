@@ -0,0 +1,79 @@
+package learn
+
+import (
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/features"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+)
+
+// Sarsa(lambda) with linear action values over the features of the state
+// (Sutton and Barto, 2018, section 12.7), bootstrapping from the action the
+// epsilon-greedy policy actually takes next
+type LinearSarsa struct {
+	linear
+}
+
+// return a linear Sarsa(lambda) learner drawing random numbers from rng
+func NewLinearSarsa(rng *random.Rand) *LinearSarsa {
+	return &LinearSarsa{linear{episodic: episodic{name: "linear_sarsa", rng: rng}}}
+}
+
+// Initialize the feature extractor, weights, and trace.
+func (self *LinearSarsa) Init(cfg *config.Config, env environment.Environment) error {
+	return self.init(cfg, env)
+}
+
+// Learn the weights
+func (self *LinearSarsa) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	var x, xp features.Vector
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		self.extractor.Extract(s.Vals, &x)
+		self.clearTrace()
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+
+		// the first action is chosen before entering the loop, and each
+		// later one when its value is needed for the update
+		aIndex, _, _ := self.epsilonGreedy(&x, self.epsilon)
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, self.actions[aIndex])
+			self.extractor.Extract(sp.Vals, &xp)
+
+			// choose the next action, and bootstrap from its value unless
+			// the episode has ended
+			apIndex, qAP, _ := self.epsilonGreedy(&xp, self.epsilon)
+			target := reward
+			if !env.AtGoalState(sp) && !env.AtFailState(sp) {
+				target += self.gamma * qAP
+			}
+
+			// mark the visited features in the trace and update the weights
+			delta := self.update(&x, aIndex, target)
+
+			s, aIndex = sp, apIndex
+			x, xp = xp, x
+			stats.Step(reward, delta)
+		}
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
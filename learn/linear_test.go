@@ -0,0 +1,111 @@
+package learn
+
+import (
+	"testing"
+
+	"github.com/deong/gorl/features"
+	"github.com/deong/gorl/random"
+)
+
+func TestLinearVisit(t *testing.T) {
+	lrn := NewLinearSarsa(random.NewRand(1))
+	lrn.alpha = 0.5
+	lrn.e = [][]float64{{0.0, 0.0, 0.0}, {0.0, 0.0, 0.0}}
+	x := &features.Vector{Index: []int{0, 2}, Value: []float64{1.0, 0.5}}
+	tests := []struct {
+		trace    string
+		expected []float64
+	}{
+		// marked twice: adding x each time, setting it to x, and adding x
+		// scaled by 1 - alpha e.x, which is 1 - 0.5*1.25 the second time
+		{"accumulating", []float64{2.0, 0.0, 1.0}},
+		{"replacing", []float64{1.0, 0.0, 0.5}},
+		{"dutch", []float64{1.375, 0.0, 0.6875}},
+	}
+	for _, tt := range tests {
+		lrn.trace = tt.trace
+		lrn.clearTrace()
+		lrn.visit(x, 1)
+		lrn.visit(x, 1)
+		if !vectorEpsilonEqual(lrn.e[1], tt.expected, 1e-12) || len(lrn.active) != 2 {
+			t.Errorf("%v trace after two visits = %v with %v active, expected %v with 2\n",
+				tt.trace, lrn.e[1], len(lrn.active), tt.expected)
+		}
+	}
+}
+
+func TestLinearVisitZero(t *testing.T) {
+	lrn := NewLinearSarsa(random.NewRand(1))
+	lrn.alpha, lrn.gamma, lrn.lambda = 0.5, 1.0, 1.0
+	lrn.trace = "accumulating"
+	lrn.w = [][]float64{{0.0, 0.0, 0.0}}
+	lrn.e = [][]float64{{0.0, 0.0, 0.0}}
+
+	// a zero feature leaves its weight inactive, and a trace cancelling to
+	// zero is dropped, so that neither is updated, nor listed twice when
+	// visited again
+	lrn.visit(&features.Vector{Index: []int{0, 1}, Value: []float64{0.0, 1.0}}, 0)
+	lrn.visit(&features.Vector{Index: []int{1, 2}, Value: []float64{-1.0, 1.0}}, 0)
+	if len(lrn.active) != 1 || lrn.active[0] != (pair{2, 0}) {
+		t.Errorf("Active set after visits = %v, expected only feature 2\n", lrn.active)
+	}
+	lrn.visit(&features.Vector{Index: []int{0, 1, 2}, Value: []float64{0.0, 1.0, 1.0}}, 0)
+	if len(lrn.active) != 2 {
+		t.Errorf("Active set after a further visit = %v, expected features 1 and 2\n", lrn.active)
+	}
+	lrn.traceUpdate(1.0)
+	expected := []float64{0.0, 0.5, 1.0}
+	if !vectorEpsilonEqual(lrn.w[0], expected, 1e-12) || !vectorEpsilonEqual(lrn.e[0], []float64{0.0, 1.0, 2.0}, 1e-12) {
+		t.Errorf("Weights and trace after an update = %v and %v, expected %v and [0 1 2]\n", lrn.w[0], lrn.e[0], expected)
+	}
+}
+
+func TestLinearUpdate(t *testing.T) {
+	sarsa, q := NewLinearSarsa(random.NewRand(1)), NewLinearQLearning(random.NewRand(1))
+	x1 := &features.Vector{Index: []int{0, 1}, Value: []float64{1.0, 0.5}}
+	x2 := &features.Vector{Index: []int{1, 2}, Value: []float64{1.0, 1.0}}
+
+	// the second update is towards a target of zero from a value of 0.25, by
+	// a step of alpha times the TD error of -0.25 along the trace, which still
+	// holds half the features of the first visit unless Watkins's Q(lambda)
+	// cut it after an exploratory action, one whose value is below the greedy
+	// value of the state
+	tests := []struct {
+		name     string
+		lrn      *linear
+		update   func(x *features.Vector, target float64) float64
+		expected []float64
+	}{
+		{"linear_sarsa", &sarsa.linear,
+			func(x *features.Vector, target float64) float64 { return sarsa.update(x, 0, target) },
+			[]float64{0.4375, 0.09375, -0.125}},
+		{"linear_qlearning greedy", &q.linear,
+			func(x *features.Vector, target float64) float64 { return q.update(x, 0, target, 0.25) },
+			[]float64{0.4375, 0.09375, -0.125}},
+		{"linear_qlearning exploratory", &q.linear,
+			func(x *features.Vector, target float64) float64 { return q.update(x, 0, target, 1.0) },
+			[]float64{0.5, 0.125, -0.125}},
+	}
+	for _, tt := range tests {
+		lrn := tt.lrn
+		lrn.alpha, lrn.gamma, lrn.lambda = 0.5, 1.0, 0.5
+		lrn.trace = "accumulating"
+		lrn.w = [][]float64{{0.0, 0.0, 0.0}, {0.0, 0.0, 0.0}}
+		lrn.e = [][]float64{{0.0, 0.0, 0.0}, {0.0, 0.0, 0.0}}
+		lrn.active = nil
+
+		if delta := tt.update(x1, 1.0); delta != 1.0 {
+			t.Errorf("%v: first TD error = %v, expected 1\n", tt.name, delta)
+		}
+		if !vectorEpsilonEqual(lrn.w[0], []float64{0.5, 0.25, 0.0}, 1e-12) {
+			t.Errorf("%v: weights after the first update = %v, expected [0.5 0.25 0]\n", tt.name, lrn.w[0])
+		}
+		if delta := tt.update(x2, 0.0); delta != -0.25 {
+			t.Errorf("%v: second TD error = %v, expected -0.25\n", tt.name, delta)
+		}
+		if !vectorEpsilonEqual(lrn.w[0], tt.expected, 1e-12) || !vectorEpsilonEqual(lrn.w[1], []float64{0.0, 0.0, 0.0}, 1e-12) {
+			t.Errorf("%v: weights after the second update = %v, expected %v and the other action's unchanged\n",
+				tt.name, lrn.w, tt.expected)
+		}
+	}
+}
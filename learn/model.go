@@ -0,0 +1,117 @@
+package learn
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/deong/gorl/random"
+)
+
+// A Transition is the outcome last observed after taking action A in state S
+// (both by id): the next state, the reward, and whether the next state is
+// terminal, so that nothing follows it.
+type Transition struct {
+	S, A     uint
+	Next     uint
+	Reward   float64
+	Terminal bool
+}
+
+// A tabular model of the environment, as learned by the model-based
+// learners: the last transition observed from each pair, and the pairs
+// observed to lead to each state. Environments are treated as deterministic,
+// so a later observation from a pair replaces the earlier one.
+type model struct {
+	transitions  []Transition    // in the order the pairs were first observed
+	index        map[pair]int    // position of each pair's transition
+	predecessors map[uint][]pair // sorted, so that they do not depend on the order of observation
+}
+
+func newModel() *model {
+	return &model{index: make(map[pair]int), predecessors: make(map[uint][]pair)}
+}
+
+// record a transition, replacing any observed before from the same pair
+func (m *model) observe(t Transition) {
+	p := pair{t.S, t.A}
+	k, seen := m.index[p]
+	if !seen {
+		m.index[p] = len(m.transitions)
+		m.transitions = append(m.transitions, t)
+		m.addPredecessor(t.Next, p)
+		return
+	}
+	if old := m.transitions[k].Next; old != t.Next {
+		m.removePredecessor(old, p)
+		m.addPredecessor(t.Next, p)
+	}
+	m.transitions[k] = t
+}
+
+// record that pair p leads to state s
+func (m *model) addPredecessor(s uint, p pair) {
+	preds := m.predecessors[s]
+	i := sort.Search(len(preds), func(i int) bool { return !preds[i].less(p) })
+	preds = append(preds, pair{})
+	copy(preds[i+1:], preds[i:])
+	preds[i] = p
+	m.predecessors[s] = preds
+}
+
+// forget that pair p leads to state s
+func (m *model) removePredecessor(s uint, p pair) {
+	preds := m.predecessors[s]
+	i := sort.Search(len(preds), func(i int) bool { return !preds[i].less(p) })
+	if i < len(preds) && preds[i] == p {
+		m.predecessors[s] = append(preds[:i], preds[i+1:]...)
+	}
+}
+
+// order pairs by state, then action
+func (p pair) less(q pair) bool {
+	return p.s < q.s || (p.s == q.s && p.a < q.a)
+}
+
+// return the transition observed from a pair, and whether there is one
+func (m *model) lookup(s, a uint) (Transition, bool) {
+	k, ok := m.index[pair{s, a}]
+	if !ok {
+		return Transition{}, false
+	}
+	return m.transitions[k], true
+}
+
+// return one of the observed transitions, chosen uniformly at random
+func (m *model) sample(rng *random.Rand) Transition {
+	return m.transitions[rng.Intn(len(m.transitions))]
+}
+
+// rebuild the model from the transitions saved in a checkpoint
+func (m *model) restore(ts []Transition, numStates, numActions int) error {
+	*m = *newModel()
+	for i, t := range ts {
+		if t.S >= uint(numStates) || t.Next >= uint(numStates) || t.A >= uint(numActions) {
+			return fmt.Errorf("model transition %v (%v, %v) -> %v is outside of the state or action space",
+				i, t.S, t.A, t.Next)
+		}
+		m.observe(t)
+	}
+	return nil
+}
+
+// return the one-step Q-learning error of a transition
+func (self *DynaQ) tdError(t Transition) float64 {
+	delta := t.Reward - self.Q[t.S][t.A]
+	if !t.Terminal {
+		_, best := self.ArgmaxAction(self.states[t.Next])
+		delta += self.gamma * best
+	}
+	return delta
+}
+
+// apply the one-step Q-learning update for a transition, returning its error
+func (self *DynaQ) qUpdate(t Transition) float64 {
+	delta := self.tdError(t)
+	self.Q[t.S][t.A] += self.alpha * delta
+	return delta
+}
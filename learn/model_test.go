@@ -0,0 +1,62 @@
+package learn
+
+import (
+	"testing"
+
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+func TestModelObserve(t *testing.T) {
+	m := newModel()
+	m.observe(Transition{S: 0, A: 1, Next: 2, Reward: -1.0})
+	m.observe(Transition{S: 1, A: 0, Next: 2, Reward: -1.0})
+	m.observe(Transition{S: 0, A: 1, Next: 3, Reward: 5.0, Terminal: true})
+
+	if len(m.transitions) != 2 {
+		t.Fatalf("Model has %v transitions: expected 2\n", len(m.transitions))
+	}
+	if tr, ok := m.lookup(0, 1); !ok || tr.Next != 3 || tr.Reward != 5.0 || !tr.Terminal {
+		t.Errorf("Transition from (0, 1) = %v: expected the later observation\n", tr)
+	}
+	if _, ok := m.lookup(2, 0); ok {
+		t.Error("Found a transition from an unobserved pair.\n")
+	}
+	if preds := m.predecessors[2]; len(preds) != 1 || preds[0] != (pair{1, 0}) {
+		t.Errorf("Predecessors of state 2 = %v: expected [{1 0}]\n", preds)
+	}
+	if preds := m.predecessors[3]; len(preds) != 1 || preds[0] != (pair{0, 1}) {
+		t.Errorf("Predecessors of state 3 = %v: expected [{0 1}]\n", preds)
+	}
+
+	if err := m.restore([]Transition{{S: 0, A: 0, Next: 4}}, 4, 2); err == nil {
+		t.Error("Expected an error restoring a transition to a state outside of the space.\n")
+	}
+}
+
+func TestDynaQUpdate(t *testing.T) {
+	tests := []struct {
+		terminal bool
+		delta    float64
+		value    float64
+		reason   string
+	}{
+		// the target bootstraps from the greedy value of state 1, 1 + 0.5 * 3
+		{false, 2.5, 1.25, "non-terminal"},
+		// nothing follows a terminal state, so the target is the reward
+		{true, 1.0, 0.5, "terminal"},
+	}
+	for _, tt := range tests {
+		lrn := NewDynaQ(random.NewRand(1))
+		lrn.alpha, lrn.gamma = 0.5, 0.5
+		lrn.states = []space.State{{Id: 0}, {Id: 1}}
+		lrn.Q = [][]float64{{0.0, 0.0}, {1.0, 3.0}}
+		tr := Transition{S: 0, A: 1, Next: 1, Reward: 1.0, Terminal: tt.terminal}
+		if delta := lrn.qUpdate(tr); delta != tt.delta {
+			t.Errorf("%v: TD error = %v, expected %v\n", tt.reason, delta, tt.delta)
+		}
+		if lrn.Q[0][1] != tt.value {
+			t.Errorf("%v: Q[0][1] = %v, expected %v\n", tt.reason, lrn.Q[0][1], tt.value)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package learn
+
+import (
+	"fmt"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// Monte Carlo control: each episode is played out in full under the
+// epsilon-greedy policy and buffered, and Q is updated at its end towards
+// the returns that followed each visit to a pair, without bootstrapping.
+// The on-policy learner averages the returns, and so learns the values of
+// the epsilon-soft policy it follows. The off-policy learner learns the
+// values of the greedy policy instead, weighting the returns by importance
+// sampling ratios (weighted importance sampling, Sutton and Barto, 2018,
+// section 5.7), so that only the tail of an episode after its last
+// exploratory action contributes.
+//
+// With visits = first, only the first visit to a pair in an episode is
+// updated; with visits = every, all of them are. Episodes cut off by
+// max_steps are treated as though they ended there. Neither alpha nor lambda
+// is used, and as there is no TD error, none is reported.
+type MonteCarlo struct {
+	tabular
+	offPolicy  bool
+	everyVisit bool
+	episode    []mcStep
+}
+
+// a step of a buffered episode: the pair visited, the reward received, and
+// the probability with which the behaviour policy chose the action
+type mcStep struct {
+	s, a   uint
+	reward float64
+	prob   float64
+}
+
+// return an on-policy Monte Carlo learner drawing random numbers from rng
+func NewMonteCarlo(rng *random.Rand) *MonteCarlo {
+	return &MonteCarlo{tabular: tabular{episodic: episodic{name: "monte_carlo", rng: rng}}}
+}
+
+// return an off-policy Monte Carlo learner drawing random numbers from rng
+func NewOffPolicyMonteCarlo(rng *random.Rand) *MonteCarlo {
+	return &MonteCarlo{tabular: tabular{episodic: episodic{name: "off_policy_monte_carlo", rng: rng}}, offPolicy: true}
+}
+
+// Initialize the Q-values table and the table of visit counts (on-policy) or
+// cumulative importance sampling weights (off-policy) by which returns are
+// averaged.
+func (self *MonteCarlo) Init(cfg *config.Config, env environment.Environment) error {
+	if err := self.init(cfg, env); err != nil {
+		return err
+	}
+	visits, err := cfg.String("learning", "visits")
+	if err != nil {
+		return err
+	}
+	if visits != "first" && visits != "every" {
+		return &config.ParamError{Section: "learning", Key: "visits",
+			Err: fmt.Errorf("unknown visits '%v' (expected first or every)", visits)}
+	}
+	self.everyVisit = visits == "every"
+
+	weights := make([][]float64, len(self.Q))
+	for i := range weights {
+		weights[i] = make([]float64, len(self.actions))
+	}
+	self.tables = map[string][][]float64{self.weightsName(): weights}
+	return nil
+}
+
+// the name of the table by which returns are averaged
+func (self *MonteCarlo) weightsName() string {
+	if self.offPolicy {
+		return "C"
+	}
+	return "N"
+}
+
+// return the probability with which the epsilon-greedy policy chooses action
+// a in state s
+func (self *MonteCarlo) behaviourProb(s space.State, a uint) float64 {
+	p := self.epsilon / float64(len(self.actions))
+	if best, _ := self.ArgmaxAction(s); a == best {
+		p += 1.0 - self.epsilon
+	}
+	return p
+}
+
+// Learn the Q-values
+func (self *MonteCarlo) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		self.DiscretizeState(&s)
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+		self.episode = self.episode[:0]
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+
+			// select an action
+			aIndex, _, _ := self.EpsilonGreedyAction(s, self.epsilon)
+			a := self.actions[aIndex]
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, a)
+			self.DiscretizeState(&sp)
+
+			step := mcStep{s: s.Id, a: a.Id, reward: reward}
+			if self.offPolicy {
+				step.prob = self.behaviourProb(s, a.Id)
+			}
+			self.episode = append(self.episode, step)
+
+			s = sp
+			stats.Step(reward, 0.0)
+		}
+		self.update()
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// update Q from the buffered episode, working backwards from its end so that
+// the return from each step can be accumulated as it goes
+func (self *MonteCarlo) update() {
+	// with first visits, a pair is only updated at the step of its first visit
+	var first map[pair]int
+	if !self.everyVisit {
+		first = make(map[pair]int)
+		for t := len(self.episode) - 1; t >= 0; t-- {
+			first[pair{self.episode[t].s, self.episode[t].a}] = t
+		}
+	}
+
+	weights := self.tables[self.weightsName()]
+	g, w := 0.0, 1.0
+	for t := len(self.episode) - 1; t >= 0; t-- {
+		step := self.episode[t]
+		g = self.gamma*g + step.reward
+		if first == nil || first[pair{step.s, step.a}] == t {
+			// on-policy, w is always one and weights counts the visits
+			weights[step.s][step.a] += w
+			self.Q[step.s][step.a] += w / weights[step.s][step.a] * (g - self.Q[step.s][step.a])
+		}
+		if !self.offPolicy {
+			continue
+		}
+
+		// the greedy policy would not have taken this action, so no
+		// earlier step's return can be credited to it
+		if best, _ := self.ArgmaxAction(space.State{Id: step.s}); best != step.a {
+			break
+		}
+		w /= step.prob
+	}
+}
@@ -0,0 +1,64 @@
+package learn
+
+import (
+	"testing"
+
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// return a Monte Carlo learner over two states and two actions
+func newTestMonteCarlo(offPolicy, everyVisit bool) *MonteCarlo {
+	lrn := NewMonteCarlo(random.NewRand(1))
+	if offPolicy {
+		lrn = NewOffPolicyMonteCarlo(random.NewRand(1))
+	}
+	lrn.everyVisit = everyVisit
+	lrn.gamma = 1.0
+	lrn.actions = make([]space.Action, 2)
+	lrn.Q = [][]float64{{0.0, 0.0}, {0.0, 0.0}}
+	lrn.tables = map[string][][]float64{lrn.weightsName(): {{0.0, 0.0}, {0.0, 0.0}}}
+	return lrn
+}
+
+func TestMonteCarloOnPolicy(t *testing.T) {
+	episode := []mcStep{{s: 0, a: 0, reward: 1.0}, {s: 1, a: 1, reward: 2.0}, {s: 0, a: 0, reward: 3.0}}
+	tests := []struct {
+		everyVisit bool
+		q00, n00   float64
+	}{
+		{false, 6.0, 1.0},            // the return from the first visit only
+		{true, (6.0 + 3.0) / 2, 2.0}, // the mean of the returns from both visits
+	}
+	for _, tt := range tests {
+		lrn := newTestMonteCarlo(false, tt.everyVisit)
+		lrn.episode = episode
+		lrn.update()
+		if lrn.Q[0][0] != tt.q00 || lrn.tables["N"][0][0] != tt.n00 || lrn.Q[1][1] != 5.0 {
+			t.Errorf("Every visit %v: Q = %v and N = %v, expected Q[0][0] = %v, N[0][0] = %v, Q[1][1] = 5\n",
+				tt.everyVisit, lrn.Q, lrn.tables["N"], tt.q00, tt.n00)
+		}
+	}
+}
+
+func TestMonteCarloOffPolicy(t *testing.T) {
+	lrn := newTestMonteCarlo(true, true)
+	lrn.episode = []mcStep{
+		{s: 0, a: 0, reward: 0.0, prob: 0.5},
+		{s: 1, a: 1, reward: -5.0, prob: 0.5},
+		{s: 0, a: 1, reward: 1.0, prob: 0.5},
+		{s: 1, a: 0, reward: 1.0, prob: 0.8},
+	}
+	lrn.update()
+
+	// working backwards: the last two actions are greedy once updated, the
+	// second is not, so the first is never reached
+	c := lrn.tables["C"]
+	expected := []struct{ q, c float64 }{{0.0, 0.0}, {-3.0, 2.5}, {2.0, 1.25}, {1.0, 1.0}}
+	for k, step := range lrn.episode {
+		if lrn.Q[step.s][step.a] != expected[k].q || c[step.s][step.a] != expected[k].c {
+			t.Errorf("Step %v: Q = %v and C = %v, expected %v and %v\n",
+				k, lrn.Q[step.s][step.a], c[step.s][step.a], expected[k].q, expected[k].c)
+		}
+	}
+}
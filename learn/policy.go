@@ -0,0 +1,193 @@
+package learn
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/deong/gorl/space"
+)
+
+// Policies are saved as JSON documents tagged with a format version. The
+// version must be bumped whenever the layout of Policy changes in a way that
+// older readers cannot handle. Version 1 held only a state lattice and its
+// Q-table; version 2 added Tables and Weights, and policies without a
+// lattice. Policies of any version from 1 to PolicyFormatVersion can be read.
+const PolicyFormatVersion = 2
+
+// A Policy is everything needed to reconstruct a learned tabular controller:
+// the state lattice and action set it was learned over, the table of action
+// values, and the hyperparameters of the learner that produced it. Learners
+// that keep further tables (e.g., the two tables of Double Q-learning, or the
+// critic's state values in actor-critic) save them in Tables, with a row per
+// state holding either a value per action or a single state value; Q is
+// always the table the policy acts on. Learners that approximate their
+// functions of the state rather than keeping tables over a lattice save no
+// States, Q, or Tables, and save their parameters in Weights instead.
+type Policy struct {
+	Version int
+	Learner string
+	States  []space.State
+	Actions []space.Action
+	Q       [][]float64
+	Tables  map[string][][]float64 `json:",omitempty"`
+	Weights map[string][][]float64 `json:",omitempty"`
+	Params  map[string]float64
+}
+
+// write a policy to the named file
+func WritePolicy(filename string, p *Policy) error {
+	p.Version = PolicyFormatVersion
+	if err := writeJSON(filename, p); err != nil {
+		return fmt.Errorf("error saving policy: %v", err)
+	}
+	return nil
+}
+
+// read a policy from the named file, checking that it is internally consistent
+func ReadPolicy(filename string) (p *Policy, err error) {
+	var f *os.File
+	if f, err = os.Open(filename); err != nil {
+		return nil, fmt.Errorf("error loading policy: %v", err)
+	}
+	defer f.Close()
+
+	p = new(Policy)
+	if err = json.NewDecoder(f).Decode(p); err != nil {
+		return nil, fmt.Errorf("error loading policy from '%v': %v", filename, err)
+	}
+	if p.Version < 1 || p.Version > PolicyFormatVersion {
+		return nil, fmt.Errorf("error loading policy from '%v': unsupported format version %v (expected 1 to %v)",
+			filename, p.Version, PolicyFormatVersion)
+	}
+	if p.Version == 1 && (len(p.Tables) != 0 || len(p.Weights) != 0) {
+		return nil, fmt.Errorf("error loading policy from '%v': format version 1 has no tables or weights", filename)
+	}
+	if err = p.check(); err != nil {
+		return nil, fmt.Errorf("error loading policy from '%v': %v", filename, err)
+	}
+	return
+}
+
+// verify that the sizes of the lattice, action set, and tables agree. Only a
+// policy without a lattice may have an empty action set, as learned by a
+// learner with continuous actions.
+func (p *Policy) check() error {
+	for i := range p.Actions {
+		if p.Actions[i].Id != uint(i) {
+			return fmt.Errorf("action %v has id %v", i, p.Actions[i].Id)
+		}
+	}
+	if !p.HasLattice() {
+		if len(p.Weights) == 0 {
+			return fmt.Errorf("policy has neither a state lattice nor weights")
+		}
+		if len(p.Q) != 0 || len(p.Tables) != 0 {
+			return fmt.Errorf("policy has tables but no state lattice")
+		}
+		return nil
+	}
+	if len(p.Actions) == 0 {
+		return fmt.Errorf("policy has an empty action space")
+	}
+	if len(p.Q) != len(p.States) {
+		return fmt.Errorf("Q-table has %v rows but there are %v states", len(p.Q), len(p.States))
+	}
+	dim := len(p.States[0].Vals)
+	for i := range p.States {
+		if p.States[i].Id != uint(i) {
+			return fmt.Errorf("state %v has id %v", i, p.States[i].Id)
+		}
+		if len(p.States[i].Vals) != dim {
+			return fmt.Errorf("state %v has %v features (expected %v)", i, len(p.States[i].Vals), dim)
+		}
+		if len(p.Q[i]) != len(p.Actions) {
+			return fmt.Errorf("Q-table row %v has %v entries but there are %v actions",
+				i, len(p.Q[i]), len(p.Actions))
+		}
+	}
+	for name, table := range p.Tables {
+		if len(table) != len(p.States) {
+			return fmt.Errorf("table %v has %v rows but there are %v states", name, len(table), len(p.States))
+		}
+		for i := range table {
+			if len(table[i]) != len(p.Actions) && len(table[i]) != 1 {
+				return fmt.Errorf("table %v row %v has %v entries but there are %v actions",
+					name, i, len(table[i]), len(p.Actions))
+			}
+		}
+	}
+	return nil
+}
+
+// check whether the policy was learned over a lattice of states, rather than
+// with function approximation
+func (p *Policy) HasLattice() bool {
+	return len(p.States) > 0
+}
+
+// return the names of the additional tables, in sorted order
+func (p *Policy) TableNames() []string {
+	return sortedKeys(p.Tables)
+}
+
+// return the names of the weights, in sorted order
+func (p *Policy) WeightNames() []string {
+	return sortedKeys(p.Weights)
+}
+
+func sortedKeys(m map[string][][]float64) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// check that a policy was learned over states with the given number of features
+func (p *Policy) CheckFeatures(n int) error {
+	if !p.HasLattice() {
+		return fmt.Errorf("policy was learned by %v without a state lattice", p.Learner)
+	}
+	if len(p.States[0].Vals) != n {
+		return fmt.Errorf("policy was learned over %v features, not %v",
+			len(p.States[0].Vals), n)
+	}
+	return nil
+}
+
+// return the lattice state nearest to s, its action values, and the index of
+// the greedy action there
+func (p *Policy) Lookup(s space.State) (nearest space.State, q []float64, greedy uint) {
+	nearest = p.States[space.NearestState(p.States, &s)]
+	q = p.Q[nearest.Id]
+	for i := 1; i < len(q); i++ {
+		if q[i] > q[greedy] {
+			greedy = uint(i)
+		}
+	}
+	return
+}
+
+// encode v as JSON into the named file. The data is written to a temporary
+// file first and renamed into place, so an interrupted write never clobbers
+// an existing file.
+func writeJSON(filename string, v interface{}) (err error) {
+	tmp := filename + ".tmp"
+	var f *os.File
+	if f, err = os.Create(tmp); err != nil {
+		return
+	}
+	if err = json.NewEncoder(f).Encode(v); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	return os.Rename(tmp, filename)
+}
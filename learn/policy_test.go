@@ -0,0 +1,133 @@
+package learn
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/deong/gorl/space"
+)
+
+func TestPolicyRoundTrip(t *testing.T) {
+	states := space.BuildLattice([][]float64{{-1.0, 0.0, 1.0}, {-0.5, 0.5}})
+	actions := []space.Action{{Id: 0, Val: -1.0}, {Id: 1, Val: 1.0}}
+	q := make([][]float64, len(states))
+	for i := range q {
+		q[i] = []float64{float64(i), -float64(i) / 3.0}
+	}
+	p := &Policy{Learner: "qlearning", States: states, Actions: actions, Q: q,
+		Params: map[string]float64{"alpha": 0.6}}
+
+	filename := filepath.Join(t.TempDir(), "policy.json")
+	if err := WritePolicy(filename, p); err != nil {
+		t.Fatalf("Error writing policy: %v\n", err)
+	}
+	lp, err := ReadPolicy(filename)
+	if err != nil {
+		t.Fatalf("Error reading policy: %v\n", err)
+	}
+	if lp.Learner != "qlearning" || lp.Params["alpha"] != 0.6 {
+		t.Errorf("Incorrect learner or parameters (%v, %v) read back.\n", lp.Learner, lp.Params)
+	}
+	if len(lp.States) != len(states) || len(lp.Actions) != len(actions) {
+		t.Fatalf("Incorrect number of states or actions read back (%v, %v).\n",
+			len(lp.States), len(lp.Actions))
+	}
+	for i := range states {
+		if !vectorEpsilonEqual(lp.States[i].Vals, states[i].Vals, 0.00001) {
+			t.Errorf("State %v: %v != %v\n", i, lp.States[i].Vals, states[i].Vals)
+		}
+		if !vectorEpsilonEqual(lp.Q[i], q[i], 0.00001) {
+			t.Errorf("Q-values for state %v: %v != %v\n", i, lp.Q[i], q[i])
+		}
+	}
+	if err := lp.CheckFeatures(3); err == nil {
+		t.Error("Expected an error checking a 2-feature policy against 3 features.\n")
+	}
+}
+
+func TestReadPolicyRejectsBadShape(t *testing.T) {
+	states := space.BuildLattice([][]float64{{0.0, 1.0}})
+	p := &Policy{States: states, Actions: []space.Action{{Id: 0, Val: 0.0}}, Q: [][]float64{{0.0}}}
+	filename := filepath.Join(t.TempDir(), "policy.json")
+	if err := WritePolicy(filename, p); err != nil {
+		t.Fatalf("Error writing policy: %v\n", err)
+	}
+	if _, err := ReadPolicy(filename); err == nil {
+		t.Error("Expected an error reading a policy with too few Q-table rows.\n")
+	}
+}
+
+func TestReadPolicyVersions(t *testing.T) {
+	lattice := func() *Policy {
+		return &Policy{States: space.BuildLattice([][]float64{{0.0, 1.0}}),
+			Actions: []space.Action{{Id: 0, Val: 0.0}}, Q: [][]float64{{0.0}, {1.0}}}
+	}
+	weights := &Policy{Weights: map[string][][]float64{"w": {{1.0}}}}
+	tests := []struct {
+		version int
+		p       *Policy
+		ok      bool
+	}{
+		{1, lattice(), true},
+		{PolicyFormatVersion, lattice(), true},
+		{PolicyFormatVersion, weights, true},
+		// version 1 predates policies without a lattice
+		{1, weights, false},
+		{0, lattice(), false},
+		{PolicyFormatVersion + 1, lattice(), false},
+	}
+	for _, tt := range tests {
+		filename := filepath.Join(t.TempDir(), "policy.json")
+		tt.p.Version = tt.version
+		if err := writeJSON(filename, tt.p); err != nil {
+			t.Fatalf("Error writing policy: %v\n", err)
+		}
+		if _, err := ReadPolicy(filename); (err == nil) != tt.ok {
+			t.Errorf("Reading a version %v policy with %v weights gave error %v\n", tt.version, len(tt.p.Weights), err)
+		}
+	}
+}
+
+func TestPolicyTablesRoundTrip(t *testing.T) {
+	states := space.BuildLattice([][]float64{{0.0, 1.0}})
+	actions := []space.Action{{Id: 0, Val: -1.0}, {Id: 1, Val: 1.0}}
+	p := &Policy{Learner: "double_qlearning", States: states, Actions: actions,
+		Q:      [][]float64{{3.0, 1.0}, {0.5, 2.0}},
+		Tables: map[string][][]float64{"B": {{2.0, 0.0}, {0.0, 1.0}}, "A": {{1.0, 1.0}, {0.5, 1.0}}}}
+	filename := filepath.Join(t.TempDir(), "policy.json")
+	if err := WritePolicy(filename, p); err != nil {
+		t.Fatalf("Error writing policy: %v\n", err)
+	}
+	lp, err := ReadPolicy(filename)
+	if err != nil {
+		t.Fatalf("Error reading policy: %v\n", err)
+	}
+	if names := lp.TableNames(); len(names) != 2 || names[0] != "A" || names[1] != "B" {
+		t.Fatalf("Table names %v read back: expected [A B].\n", names)
+	}
+	for name, table := range p.Tables {
+		for i := range table {
+			if !vectorEpsilonEqual(lp.Tables[name][i], table[i], 0.00001) {
+				t.Errorf("%v-values for state %v: %v != %v\n", name, i, lp.Tables[name][i], table[i])
+			}
+		}
+	}
+
+	p.Tables["A"] = p.Tables["A"][:1]
+	if err := WritePolicy(filename, p); err != nil {
+		t.Fatalf("Error writing policy: %v\n", err)
+	}
+	if _, err := ReadPolicy(filename); err == nil {
+		t.Error("Expected an error reading a policy with too few rows in table A.\n")
+	}
+}
+
+func vectorEpsilonEqual(v1, v2 []float64, epsilon float64) bool {
+	for i := range v1 {
+		if math.Abs(v1[i]-v2[i]) >= epsilon {
+			return false
+		}
+	}
+	return true
+}
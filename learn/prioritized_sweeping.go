@@ -0,0 +1,147 @@
+package learn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+)
+
+// Prioritized sweeping (Sutton and Barto, 2018, section 8.4): like Dyna-Q,
+// real transitions are recorded in a tabular model, but rather than drawing
+// the transitions to plan with at random, the pairs whose values would change
+// most are updated first. Each real step queues its pair with the magnitude
+// of its TD error as priority, if that exceeds priority_threshold. Then up to
+// planning_steps pairs are taken from the front of the queue and updated from
+// the model, and each update queues the pairs observed to lead into the
+// updated state, so that changes propagate backwards. Lambda is not used.
+// It shares Dyna-Q's model, and adds the queue.
+type PrioritizedSweeping struct {
+	DynaQ
+	threshold float64
+	queue     *priorityQueue
+}
+
+// return a prioritized sweeping learner drawing random numbers from rng
+func NewPrioritizedSweeping(rng *random.Rand) *PrioritizedSweeping {
+	return &PrioritizedSweeping{DynaQ: DynaQ{tabular: tabular{episodic: episodic{name: "prioritized_sweeping", rng: rng}}}}
+}
+
+// Initialize the Q-values table, an empty model, and an empty queue.
+func (self *PrioritizedSweeping) Init(cfg *config.Config, env environment.Environment) (err error) {
+	if err = self.DynaQ.Init(cfg, env); err != nil {
+		return
+	}
+	if self.threshold, err = cfg.Float64("learning", "priority_threshold"); err != nil {
+		return
+	}
+	self.queue = newPriorityQueue()
+	return nil
+}
+
+// Replace the state lattice, actions, and Q-values with those from a saved
+// policy, and start an empty model and queue. Must be called after Init.
+func (self *PrioritizedSweeping) LoadPolicy(filename string) error {
+	if err := self.DynaQ.LoadPolicy(filename); err != nil {
+		return err
+	}
+	self.queue = newPriorityQueue()
+	return nil
+}
+
+// Save the complete training state, including the model and queue, so that
+// learning can be resumed later
+func (self *PrioritizedSweeping) SaveCheckpoint(filename string, env environment.Environment) error {
+	c := self.tableCheckpoint(env)
+	c.Model, c.Queue = self.model.transitions, self.queue.items
+	return WriteCheckpoint(filename, c)
+}
+
+// Restore the training state saved by SaveCheckpoint. Must be called after
+// Init; a subsequent call to Learn continues from the epoch following the
+// checkpoint.
+func (self *PrioritizedSweeping) LoadCheckpoint(filename string, env environment.Environment) error {
+	c, err := self.loadModelCheckpoint(filename)
+	if err != nil {
+		return err
+	}
+	if err = self.queue.restore(c.Queue, len(self.states), len(self.actions)); err != nil {
+		return fmt.Errorf("error loading checkpoint from '%v': %v", filename, err)
+	}
+	self.resume(c, env)
+	return nil
+}
+
+// queue a pair from the model if its TD error is large enough
+func (self *PrioritizedSweeping) prioritize(t Transition) {
+	if p := math.Abs(self.tdError(t)); p > self.threshold {
+		self.queue.raise(t.S, t.A, p)
+	}
+}
+
+// update up to planning_steps pairs from the front of the queue, queueing
+// the predecessors of each
+func (self *PrioritizedSweeping) sweep() {
+	for n := uint(0); n < self.planningSteps && self.queue.Len() > 0; n++ {
+		p := self.queue.pop()
+		queued, _ := self.model.lookup(p.S, p.A)
+		self.qUpdate(queued)
+		for _, pred := range self.model.predecessors[p.S] {
+			prev, _ := self.model.lookup(pred.s, pred.a)
+			self.prioritize(prev)
+		}
+	}
+}
+
+// Learn the Q-values
+func (self *PrioritizedSweeping) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		self.DiscretizeState(&s)
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+
+			// select an action
+			aIndex, _, _ := self.EpsilonGreedyAction(s, self.epsilon)
+			a := self.actions[aIndex]
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, a)
+			self.DiscretizeState(&sp)
+
+			// record the step in the model, and queue it; as in Dyna-Q,
+			// running out of time is not recorded as reaching a terminal state
+			t := Transition{S: s.Id, A: a.Id, Next: sp.Id, Reward: reward,
+				Terminal: environment.Terminal(env, sp)}
+			self.model.observe(t)
+			delta := self.tdError(t)
+			self.prioritize(t)
+
+			// update the most urgent pairs, queueing their predecessors
+			self.sweep()
+
+			s = sp
+			stats.Step(reward, delta)
+		}
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
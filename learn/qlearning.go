@@ -0,0 +1,140 @@
+package learn
+
+import (
+	"fmt"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// Q(lambda) learning over a lattice of states. Two variants are available:
+// Watkins's Q(lambda) cuts the trace whenever an exploratory action is taken,
+// so that it only backs up returns of the greedy policy, while Peng's
+// Q(lambda) never cuts it, backing up a mixture of the returns of the greedy
+// and the behaviour policies.
+type QLearning struct {
+	tabular
+	peng bool
+}
+
+// return a Q-learner drawing random numbers from rng
+func NewQLearning(rng *random.Rand) *QLearning {
+	return &QLearning{tabular: tabular{episodic: episodic{name: "qlearning", rng: rng}}}
+}
+
+// Initialize the Q-values table and trace.
+func (self *QLearning) Init(cfg *config.Config, env environment.Environment) error {
+	if err := self.init(cfg, env); err != nil {
+		return err
+	}
+	variant, err := cfg.String("learning", "q_lambda")
+	if err != nil {
+		return err
+	}
+	if variant != "watkins" && variant != "peng" {
+		return &config.ParamError{Section: "learning", Key: "q_lambda",
+			Err: fmt.Errorf("unknown Q(lambda) variant '%v' (expected watkins or peng)", variant)}
+	}
+	self.peng = variant == "peng"
+	return nil
+}
+
+// Learn the Q-values
+func (self *QLearning) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		// fmt.Println("*************************************************")
+		// fmt.Printf( "* starting epoch %v\n", epoch)
+		// fmt.Println("*************************************************")
+		env.Reset()
+		s := env.StartState()
+		self.DiscretizeState(&s)
+		self.clearTrace()
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+			// fmt.Printf("s:  %v\n", s)
+
+			// select an action
+			aIndex, _, _ := self.EpsilonGreedyAction(s, self.epsilon)
+			a := self.actions[aIndex]
+			_, valueOfS := self.ArgmaxAction(s)
+			// fmt.Printf("a:  %v\n", a)
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, a)
+			self.DiscretizeState(&sp)
+			// fmt.Printf("r:  %v\n", reward)
+			// fmt.Printf("s': %v\n", sp)
+
+			// calculate optimum value of next action, unless the episode has ended
+			target := reward
+			if !env.AtGoalState(sp) && !env.AtFailState(sp) {
+				_, argmaxAP := self.ArgmaxAction(sp)
+				target += self.gamma * argmaxAP
+			}
+
+			// calculate error, and update the policy
+			delta := self.update(s, a, target, valueOfS)
+
+			// print the Q-values
+			// for i := range self.Q {
+			// 	fmt.Printf("state %v (%v):\t\t%v\n", i, self.states[i], self.Q[i])
+			// }
+			// fmt.Println("")
+
+			// iterate the policy
+			s = sp
+
+			stats.Step(reward, delta)
+		}
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// update the Q-values after taking action a in state s, whose greedy value
+// was valueOfS, towards the given one-step target, marking the pair in the
+// trace as the variant requires. Returns the TD error of the pair.
+func (self *QLearning) update(s space.State, a space.Action, target, valueOfS float64) float64 {
+	// calculate error
+	delta := target - self.Q[s.Id][a.Id]
+
+	if self.peng {
+		// the earlier pairs in the trace are backed up through the greedy
+		// value of s, and the visited pair by one step only. Marking the
+		// pair before the update folds both into one pass over the table,
+		// after which the visited pair's share of the first backup is
+		// exchanged for the second.
+		before := self.E[s.Id][a.Id]
+		self.visit(s, a)
+		marked := self.E[s.Id][a.Id]
+		deltaS := target - valueOfS
+		self.traceUpdate(deltaS)
+		self.Q[s.Id][a.Id] += self.alpha * (delta - deltaS*(marked-before))
+	} else {
+		// an exploratory action ends the greedy policy's return, so the
+		// trace of the pairs before it is cut
+		if self.Q[s.Id][a.Id] < valueOfS {
+			self.clearTrace()
+		}
+		self.visit(s, a)
+		self.traceUpdate(delta)
+	}
+	return delta
+}
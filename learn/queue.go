@@ -0,0 +1,86 @@
+package learn
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// A Priority is a state-action pair (by id) waiting in a priority queue
+type Priority struct {
+	S, A     uint
+	Priority float64
+}
+
+// A max-priority queue of state-action pairs, each appearing at most once,
+// as kept by prioritized sweeping
+type priorityQueue struct {
+	items []Priority
+	index map[pair]int // position of each queued pair in items
+}
+
+func newPriorityQueue() *priorityQueue {
+	return &priorityQueue{index: make(map[pair]int)}
+}
+
+// queue a pair with the given priority, or raise its priority if it is
+// already queued with a lower one
+func (q *priorityQueue) raise(s, a uint, priority float64) {
+	if k, ok := q.index[pair{s, a}]; ok {
+		if priority > q.items[k].Priority {
+			q.items[k].Priority = priority
+			heap.Fix(q, k)
+		}
+		return
+	}
+	heap.Push(q, Priority{S: s, A: a, Priority: priority})
+}
+
+// remove and return the pair with the highest priority
+func (q *priorityQueue) pop() Priority {
+	return heap.Pop(q).(Priority)
+}
+
+// rebuild the queue from the items saved in a checkpoint, which are already
+// in heap order
+func (q *priorityQueue) restore(items []Priority, numStates, numActions int) error {
+	*q = *newPriorityQueue()
+	for i, p := range items {
+		if p.S >= uint(numStates) || p.A >= uint(numActions) {
+			return fmt.Errorf("queued pair %v (%v, %v) is outside of the state or action space", i, p.S, p.A)
+		}
+		q.items = append(q.items, p)
+		q.index[pair{p.S, p.A}] = i
+	}
+	heap.Init(q)
+	return nil
+}
+
+// heap.Interface, which is not meant to be used directly
+
+func (q *priorityQueue) Len() int {
+	return len(q.items)
+}
+
+func (q *priorityQueue) Less(i, j int) bool {
+	return q.items[i].Priority > q.items[j].Priority
+}
+
+func (q *priorityQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.index[pair{q.items[i].S, q.items[i].A}] = i
+	q.index[pair{q.items[j].S, q.items[j].A}] = j
+}
+
+func (q *priorityQueue) Push(x interface{}) {
+	p := x.(Priority)
+	q.index[pair{p.S, p.A}] = len(q.items)
+	q.items = append(q.items, p)
+}
+
+func (q *priorityQueue) Pop() interface{} {
+	last := len(q.items) - 1
+	p := q.items[last]
+	q.items = q.items[:last]
+	delete(q.index, pair{p.S, p.A})
+	return p
+}
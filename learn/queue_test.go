@@ -0,0 +1,68 @@
+package learn
+
+import (
+	"testing"
+
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+func TestPriorityQueue(t *testing.T) {
+	q := newPriorityQueue()
+	q.raise(0, 0, 1.0)
+	q.raise(1, 0, 3.0)
+	q.raise(2, 1, 2.0)
+	q.raise(0, 0, 4.0) // raised to the front
+	q.raise(1, 0, 0.5) // already queued with a higher priority
+
+	expected := []Priority{{0, 0, 4.0}, {1, 0, 3.0}, {2, 1, 2.0}}
+	if q.Len() != len(expected) {
+		t.Fatalf("Queue holds %v pairs: expected %v\n", q.Len(), len(expected))
+	}
+	for _, e := range expected {
+		if p := q.pop(); p != e {
+			t.Errorf("Popped %v: expected %v\n", p, e)
+		}
+	}
+	if len(q.index) != 0 {
+		t.Errorf("Index of an empty queue = %v\n", q.index)
+	}
+}
+
+func TestPrioritizedSweep(t *testing.T) {
+	tests := []struct {
+		threshold float64
+		steps     uint
+		q00, q10  float64
+		queued    int
+		reason    string
+	}{
+		// the update of (1, 0) queues its predecessor (0, 0) with the TD
+		// error it now has, 0.5 * 0.5, which is updated in turn if there
+		// are planning steps left and it exceeds the threshold
+		{0.0, 1, 0.0, 0.5, 1, "one planning step"},
+		{0.0, 2, 0.125, 0.5, 0, "two planning steps"},
+		{0.3, 2, 0.0, 0.5, 0, "a predecessor below the threshold"},
+	}
+	for _, tt := range tests {
+		lrn := NewPrioritizedSweeping(random.NewRand(1))
+		lrn.alpha, lrn.gamma = 0.5, 0.5
+		lrn.threshold, lrn.planningSteps = tt.threshold, tt.steps
+		lrn.states = []space.State{{Id: 0}, {Id: 1}, {Id: 2}}
+		lrn.Q = [][]float64{{0.0}, {0.0}, {0.0}}
+		lrn.model, lrn.queue = newModel(), newPriorityQueue()
+
+		// state 0 leads to 1, and 1 to the terminal state 2 with a reward
+		// of 1, which is queued as it would be on the real step
+		lrn.model.observe(Transition{S: 0, A: 0, Next: 1})
+		last := Transition{S: 1, A: 0, Next: 2, Reward: 1.0, Terminal: true}
+		lrn.model.observe(last)
+		lrn.prioritize(last)
+
+		lrn.sweep()
+		if lrn.Q[0][0] != tt.q00 || lrn.Q[1][0] != tt.q10 || lrn.queue.Len() != tt.queued {
+			t.Errorf("%v: Q = %v and %v with %v queued, expected %v and %v with %v\n",
+				tt.reason, lrn.Q[0][0], lrn.Q[1][0], lrn.queue.Len(), tt.q00, tt.q10, tt.queued)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package learn
+
+import (
+	"fmt"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// REINFORCE (Williams, 1992; Sutton and Barto, 2018, sections 13.3 and 13.4):
+// Monte Carlo policy gradient, without a lattice of states. The policy is the
+// softmax, at the given temperature, of preferences for each action that are
+// linear in the features of the state: a constant, and each of State.Vals
+// scaled from its range in Features() to [-1, 1]. Each episode is played out
+// in full and buffered, and the preferences' weights then take a step of size
+// actor_alpha along the gradient of the log policy at each step, scaled by
+// the return from that step. With a baseline, a linear estimate of the value
+// of the state, learned with step size critic_alpha, is subtracted from each
+// return, which leaves the gradient unbiased but reduces its variance.
+//
+// Both steps are taken with the weights as they were during the episode, and
+// as is usual in practice, they are not discounted by the time within the
+// episode. Acting greedily takes the mode of the policy; with follow =
+// sample, gorl run and FollowPolicy sample from it instead.
+type Reinforce struct {
+	episodic
+	actions     []space.Action
+	ranges      []space.Range
+	theta       [][]float64 // the preferences' weights, by action
+	w           []float64   // the baseline's weights, if there is one
+	baseline    bool
+	alpha       float64
+	criticAlpha float64
+	gamma       float64
+	temperature float64
+	sample      bool
+
+	x, prefs, probs []float64 // the features and policy in the current state
+	episode         []pgStep
+}
+
+// a step of a buffered episode: the features of the state, the action taken,
+// the policy it was taken from, and the reward received
+type pgStep struct {
+	x      []float64
+	a      uint
+	probs  []float64
+	reward float64
+}
+
+// return a REINFORCE learner drawing random numbers from rng
+func NewReinforce(rng *random.Rand) *Reinforce {
+	return &Reinforce{episodic: episodic{name: "reinforce", rng: rng}}
+}
+
+// return a REINFORCE learner with a learned baseline drawing random numbers
+// from rng
+func NewReinforceBaseline(rng *random.Rand) *Reinforce {
+	return &Reinforce{episodic: episodic{name: "reinforce_baseline", rng: rng}, baseline: true}
+}
+
+// Build the action space, initialize the weights, and read the learning
+// parameters.
+func (self *Reinforce) Init(cfg *config.Config, env environment.Environment) (err error) {
+	if err = self.initEpisodes(cfg); err != nil {
+		return
+	}
+	if self.actions, err = space.BuildActionSpace(cfg, env.ActionRange()); err != nil {
+		return
+	}
+	self.ranges = env.Features()
+	if self.alpha, err = cfg.Float64("learning", "actor_alpha"); err != nil {
+		return
+	}
+	if self.criticAlpha, err = cfg.Float64("learning", "critic_alpha"); err != nil {
+		return
+	}
+	if self.gamma, err = cfg.Float64("learning", "gamma"); err != nil {
+		return
+	}
+	if self.temperature, err = cfg.Float64("learning", "temperature"); err != nil {
+		return
+	}
+	if self.temperature <= 0.0 {
+		return &config.ParamError{Section: "learning", Key: "temperature",
+			Err: fmt.Errorf("temperature must be positive, not %v", self.temperature)}
+	}
+	follow, err := cfg.String("learning", "follow")
+	if err != nil {
+		return
+	}
+	if follow != "mode" && follow != "sample" {
+		return &config.ParamError{Section: "learning", Key: "follow",
+			Err: fmt.Errorf("unknown follow '%v' (expected mode or sample)", follow)}
+	}
+	self.sample = follow == "sample"
+
+	numFeatures := len(self.ranges) + 1
+	self.theta = make([][]float64, len(self.actions))
+	for i := range self.theta {
+		self.theta[i] = make([]float64, numFeatures)
+	}
+	if self.baseline {
+		self.w = make([]float64, numFeatures)
+	}
+	self.x = make([]float64, numFeatures)
+	self.prefs = make([]float64, len(self.actions))
+	self.probs = make([]float64, len(self.actions))
+	return nil
+}
+
+// set the learner's features to those of state s, and return them
+func (self *Reinforce) features(s space.State) []float64 {
+	self.x[0] = 1.0
+	for i, r := range self.ranges {
+		self.x[i+1] = 2.0*(s.Vals[i]-r.Min)/(r.Max-r.Min) - 1.0
+	}
+	return self.x
+}
+
+// return the preferences for each action in state s
+func (self *Reinforce) preferences(s space.State) []float64 {
+	x := self.features(s)
+	for a := range self.theta {
+		self.prefs[a] = dot(self.theta[a], x)
+	}
+	return self.prefs
+}
+
+// return the dot product of two vectors of the same length
+func dot(u, v []float64) float64 {
+	sum := 0.0
+	for i := range u {
+		sum += u[i] * v[i]
+	}
+	return sum
+}
+
+// Return the probabilities with which the policy takes each action in state
+// s. The slice is reused by the next call.
+func (self *Reinforce) Probabilities(s space.State) []float64 {
+	softmax(self.preferences(s), self.temperature, self.probs)
+	return self.probs
+}
+
+// Return the action with the highest preference in a state, and the preference
+func (self *Reinforce) ArgmaxAction(s space.State) (indexOfBest uint, valueOfBest float64) {
+	prefs := self.preferences(s)
+	indexOfBest, valueOfBest = 0, prefs[0]
+	for i := 1; i < len(prefs); i++ {
+		if prefs[i] > valueOfBest {
+			indexOfBest, valueOfBest = uint(i), prefs[i]
+		}
+	}
+	return
+}
+
+// Return a random action and its preference
+func (self *Reinforce) RandomAction(s space.State) (indexOfBest uint, valueOfBest float64) {
+	indexOfBest = uint(self.rng.Intn(len(self.actions)))
+	valueOfBest = self.preferences(s)[indexOfBest]
+	return
+}
+
+// Return an epsilon-greedy action, its preference, and whether it was chosen greedily
+func (self *Reinforce) EpsilonGreedyAction(s space.State, epsilon float64) (indexOfBest uint, valueOfBest float64, wasGreedy bool) {
+	if self.rng.Float64() < epsilon {
+		indexOfBest, valueOfBest = self.RandomAction(s)
+		return indexOfBest, valueOfBest, false
+	}
+	indexOfBest, valueOfBest = self.ArgmaxAction(s)
+	return indexOfBest, valueOfBest, true
+}
+
+// Return the set of actions the learner chooses from
+func (self *Reinforce) Actions() []space.Action {
+	return self.actions
+}
+
+// Return the mode of the policy in a state
+func (self *Reinforce) GreedyAction(s space.State) space.Action {
+	aIndex, _ := self.ArgmaxAction(s)
+	return self.actions[aIndex]
+}
+
+// Return the action taken when following the policy in a state: sampled
+// from the policy with follow = sample, and its mode otherwise
+func (self *Reinforce) FollowAction(s space.State) space.Action {
+	if !self.sample {
+		return self.GreedyAction(s)
+	}
+	return self.actions[sample(self.Probabilities(s), self.rng)]
+}
+
+// Follow the policy for an episode, sampling from it or taking its mode as
+// set by follow, and printing each action taken
+func (self *Reinforce) FollowPolicy(env environment.Environment) {
+	self.follow(env, self.FollowAction)
+}
+
+// the weights, as saved in policies and checkpoints
+func (self *Reinforce) weights() map[string][][]float64 {
+	weights := map[string][][]float64{"theta": self.theta}
+	if self.baseline {
+		weights["w"] = [][]float64{self.w}
+	}
+	return weights
+}
+
+// replace the actions and weights with those of a saved policy, which must
+// have been learned over the same number of features
+func (self *Reinforce) loadWeights(p *Policy) error {
+	theta, ok := p.Weights["theta"]
+	if !ok {
+		return fmt.Errorf("policy learned by %v has no policy weights", p.Learner)
+	}
+	if len(theta) != len(p.Actions) {
+		return fmt.Errorf("policy has weights for %v actions, not %v", len(theta), len(p.Actions))
+	}
+	for i := range theta {
+		if len(theta[i]) != len(self.x) {
+			return fmt.Errorf("policy was learned over %v features, not %v", len(theta[i]), len(self.x))
+		}
+	}
+	self.actions, self.theta = p.Actions, theta
+	self.prefs = make([]float64, len(self.actions))
+	self.probs = make([]float64, len(self.actions))
+	if !self.baseline {
+		return nil
+	}
+	if w, ok := p.Weights["w"]; ok && len(w) == 1 && len(w[0]) == len(self.x) {
+		self.w = w[0]
+	} else {
+		self.w = make([]float64, len(self.x))
+	}
+	return nil
+}
+
+// Save the actions and weights to a file
+func (self *Reinforce) SavePolicy(filename string) error {
+	p := &Policy{
+		Learner: self.name,
+		Actions: self.actions,
+		Weights: self.weights(),
+		Params: map[string]float64{
+			"actor_alpha":  self.alpha,
+			"critic_alpha": self.criticAlpha,
+			"gamma":        self.gamma,
+			"temperature":  self.temperature,
+		},
+	}
+	return WritePolicy(filename, p)
+}
+
+// Replace the actions and weights with those from a saved policy. Must be
+// called after Init; the learning parameters from the configuration file are
+// left untouched. A policy without a baseline leaves the baseline at zero.
+func (self *Reinforce) LoadPolicy(filename string) error {
+	p, err := ReadPolicy(filename)
+	if err != nil {
+		return err
+	}
+	if err = self.loadWeights(p); err != nil {
+		return fmt.Errorf("error loading policy from '%v': %v", filename, err)
+	}
+	return nil
+}
+
+// Save the complete training state so that learning can be resumed later
+func (self *Reinforce) SaveCheckpoint(filename string, env environment.Environment) error {
+	c := self.checkpoint(env)
+	c.Policy.Actions, c.Policy.Weights = self.actions, self.weights()
+	return WriteCheckpoint(filename, c)
+}
+
+// Restore the training state saved by SaveCheckpoint. Must be called after
+// Init; a subsequent call to Learn continues from the epoch following the
+// checkpoint.
+func (self *Reinforce) LoadCheckpoint(filename string, env environment.Environment) error {
+	c, err := self.readCheckpoint(filename)
+	if err != nil {
+		return err
+	}
+	if err = self.loadWeights(&c.Policy); err != nil {
+		return fmt.Errorf("error loading checkpoint from '%v': %v", filename, err)
+	}
+	self.resume(c, env)
+	return nil
+}
+
+// Learn the policy
+func (self *Reinforce) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+		self.episode = self.episode[:0]
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+
+			// sample an action from the policy
+			probs := self.Probabilities(s)
+			aIndex := sample(probs, self.rng)
+			step := pgStep{
+				x:     append([]float64(nil), self.x...),
+				a:     aIndex,
+				probs: append([]float64(nil), probs...),
+			}
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, self.actions[aIndex])
+			step.reward = reward
+			self.episode = append(self.episode, step)
+
+			s = sp
+			stats.Step(reward, 0.0)
+		}
+		self.update()
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// update the weights from the buffered episode, working backwards from its
+// end so that the return from each step can be accumulated as it goes
+func (self *Reinforce) update() {
+	dTheta := make([][]float64, len(self.theta))
+	for i := range dTheta {
+		dTheta[i] = make([]float64, len(self.x))
+	}
+	var dW []float64
+	if self.baseline {
+		dW = make([]float64, len(self.x))
+	}
+
+	g := 0.0
+	for t := len(self.episode) - 1; t >= 0; t-- {
+		step := self.episode[t]
+		g = self.gamma*g + step.reward
+		delta := g
+		if self.baseline {
+			delta -= dot(self.w, step.x)
+			for i, xi := range step.x {
+				dW[i] += delta * xi
+			}
+		}
+
+		// the gradient of the log softmax policy with respect to the
+		// weights of each action's preference
+		for b, p := range step.probs {
+			grad := -p
+			if uint(b) == step.a {
+				grad += 1.0
+			}
+			grad *= delta / self.temperature
+			for i, xi := range step.x {
+				dTheta[b][i] += grad * xi
+			}
+		}
+	}
+
+	for b := range self.theta {
+		for i := range self.theta[b] {
+			self.theta[b][i] += self.alpha * dTheta[b][i]
+		}
+	}
+	for i := range dW {
+		self.w[i] += self.criticAlpha * dW[i]
+	}
+}
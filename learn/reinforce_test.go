@@ -0,0 +1,73 @@
+package learn
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+func TestReinforceFeatures(t *testing.T) {
+	lrn := NewReinforce(random.NewRand(1))
+	lrn.ranges = []space.Range{{Min: -1.0, Max: 3.0}, {Min: 0.0, Max: 10.0}}
+	lrn.x = make([]float64, 3)
+	x := lrn.features(space.State{Vals: []float64{2.0, 0.0}})
+	if expected := []float64{1.0, 0.5, -1.0}; !vectorEpsilonEqual(x, expected, 1e-12) {
+		t.Errorf("Features = %v, expected %v\n", x, expected)
+	}
+}
+
+func TestReinforceUpdate(t *testing.T) {
+	lrn := NewReinforceBaseline(random.NewRand(1))
+	lrn.theta = [][]float64{{0.0, 0.0}, {0.0, 0.0}}
+	lrn.w = []float64{1.0, 0.0}
+	lrn.x = make([]float64, 2)
+	lrn.alpha, lrn.criticAlpha, lrn.gamma, lrn.temperature = 0.5, 0.25, 0.5, 1.0
+
+	// the return from the first step is 1 + 0.5*2 = 2, and from the second 2,
+	// so both are one above the baseline of 1
+	lrn.episode = []pgStep{
+		{x: []float64{1.0, 1.0}, a: 0, probs: []float64{0.5, 0.5}, reward: 1.0},
+		{x: []float64{1.0, -1.0}, a: 1, probs: []float64{0.25, 0.75}, reward: 2.0},
+	}
+	lrn.update()
+
+	// theta[b] += 0.5 * sum over steps of (1[b=a] - p_b) * x
+	expected := [][]float64{{0.5 * (0.5 - 0.25), 0.5 * (0.5 + 0.25)}, {0.5 * (-0.5 + 0.25), 0.5 * (-0.5 - 0.25)}}
+	for b := range expected {
+		if !vectorEpsilonEqual(lrn.theta[b], expected[b], 1e-12) {
+			t.Errorf("theta[%v] = %v, expected %v\n", b, lrn.theta[b], expected[b])
+		}
+	}
+	if expected := []float64{1.5, 0.0}; !vectorEpsilonEqual(lrn.w, expected, 1e-12) {
+		t.Errorf("Baseline weights = %v, expected %v\n", lrn.w, expected)
+	}
+}
+
+func TestReinforceFollow(t *testing.T) {
+	lrn := NewReinforce(random.NewRand(1))
+	lrn.ranges = []space.Range{{Min: -1.0, Max: 1.0}}
+	lrn.actions = []space.Action{{Id: 0, Val: -1.0}, {Id: 1, Val: 1.0}}
+	lrn.theta = [][]float64{{0.0, 0.0}, {math.Log(3.0), 0.0}}
+	lrn.x, lrn.prefs, lrn.probs = make([]float64, 2), make([]float64, 2), make([]float64, 2)
+	lrn.temperature = 1.0
+	s := space.State{Vals: []float64{0.0}}
+
+	// the mode is always action 1, while sampling takes action 0 a quarter
+	// of the time
+	for _, sample := range []bool{false, true} {
+		lrn.sample = sample
+		policy := Follow(lrn)
+		counts := make([]int, 2)
+		for i := 0; i < 1000; i++ {
+			counts[policy(s).Id]++
+		}
+		if !sample && counts[0] != 0 {
+			t.Errorf("Following the mode took action 0 %v times in 1000, expected never\n", counts[0])
+		}
+		if sample && (counts[0] < 200 || counts[0] > 300) {
+			t.Errorf("Sampling took action 0 %v times in 1000, expected about 250\n", counts[0])
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package learn
+
+import (
+	"fmt"
+
+	"github.com/deong/gorl/random"
+)
+
+// An Experience is a transition observed by a learner that replays its
+// experience, with the states given as the inputs of its network, and whether
+// the next state is terminal
+type Experience struct {
+	S        []float64
+	A        uint
+	Reward   float64
+	Next     []float64
+	Terminal bool
+}
+
+// A replay buffer keeps the most recent experiences, up to its capacity,
+// from which batches are drawn uniformly at random
+type replayBuffer struct {
+	items    []Experience
+	next     int // the slot the next experience replaces once the buffer is full
+	capacity int
+}
+
+func newReplayBuffer(capacity int) *replayBuffer {
+	return &replayBuffer{capacity: capacity}
+}
+
+// add an experience, replacing the oldest one if the buffer is full
+func (b *replayBuffer) add(e Experience) {
+	if len(b.items) < b.capacity {
+		b.items = append(b.items, e)
+		return
+	}
+	b.items[b.next] = e
+	b.next = (b.next + 1) % b.capacity
+}
+
+// draw n experiences uniformly at random, with replacement, into batch
+func (b *replayBuffer) sample(rng *random.Rand, n int, batch []Experience) []Experience {
+	batch = batch[:0]
+	for i := 0; i < n; i++ {
+		batch = append(batch, b.items[rng.Intn(len(b.items))])
+	}
+	return batch
+}
+
+// rebuild the buffer from the experiences and next slot saved in a
+// checkpoint, checking that the states have the given number of inputs
+func (b *replayBuffer) restore(items []Experience, next, numInputs, numActions int) error {
+	if len(items) > b.capacity || (next != 0 && len(items) < b.capacity) || next >= b.capacity {
+		return fmt.Errorf("replay buffer of %v experiences (next %v) does not fit a capacity of %v",
+			len(items), next, b.capacity)
+	}
+	for i, e := range items {
+		if len(e.S) != numInputs || len(e.Next) != numInputs || e.A >= uint(numActions) {
+			return fmt.Errorf("experience %v does not match the network's inputs or the actions", i)
+		}
+	}
+	b.items, b.next = items, next
+	return nil
+}
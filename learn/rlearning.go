@@ -0,0 +1,195 @@
+package learn
+
+import (
+	"fmt"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// R-learning (Schwartz, 1993): average-reward control over a lattice of
+// states, learning the values of actions relative to rho, the estimated
+// reward per step of the greedy policy, rather than discounted returns. Rho
+// is updated with step size beta after each greedy action. The end of an
+// episode is treated as a state of relative value zero, after which
+// learning continues from a new start state with the same estimate of rho;
+// as the estimate is what matters, the average reward over each window of
+// reward_window steps, which runs on across episodes, is reported alongside
+// it, and recorded in the metrics window file. Gamma and lambda are not used.
+type RLearning struct {
+	tabular
+	rho    float64
+	beta   float64
+	steps  uint // steps taken over all episodes
+	window metrics.RewardWindow
+}
+
+// return an R-learner drawing random numbers from rng
+func NewRLearning(rng *random.Rand) *RLearning {
+	return &RLearning{tabular: tabular{episodic: episodic{name: "rlearning", rng: rng}}}
+}
+
+// Initialize the Q-values table and the average reward.
+func (self *RLearning) Init(cfg *config.Config, env environment.Environment) (err error) {
+	if err = self.init(cfg, env); err != nil {
+		return
+	}
+	if self.beta, err = cfg.Float64("learning", "beta"); err != nil {
+		return
+	}
+	var size uint
+	if size, err = cfg.Uint("learning", "reward_window"); err != nil {
+		return
+	}
+	self.rho, self.steps = 0.0, 0
+	self.window = metrics.RewardWindow{Size: size}
+	return nil
+}
+
+// Save the learned state lattice, actions, Q-values, and average reward to
+// a file
+func (self *RLearning) SavePolicy(filename string) error {
+	p := &Policy{
+		Learner: self.name,
+		States:  self.states,
+		Actions: self.actions,
+		Q:       self.Q,
+		Params: map[string]float64{
+			"alpha":   self.alpha,
+			"beta":    self.beta,
+			"epsilon": self.epsilon,
+			"rho":     self.rho,
+		},
+	}
+	return WritePolicy(filename, p)
+}
+
+// Replace the state lattice, actions, and Q-values with those from a saved
+// policy, along with the average reward if the policy was learned by an
+// R-learner. Must be called after Init; the learning parameters from the
+// configuration file are left untouched.
+func (self *RLearning) LoadPolicy(filename string) error {
+	if err := self.tabular.LoadPolicy(filename); err != nil {
+		return err
+	}
+	p, err := ReadPolicy(filename)
+	if err != nil {
+		return err
+	}
+	if rho, ok := p.Params["rho"]; ok && p.Learner == self.name {
+		self.rho = rho
+	}
+	return nil
+}
+
+// Save the complete training state, including the average reward and the
+// current reward window, so that learning can be resumed later
+func (self *RLearning) SaveCheckpoint(filename string, env environment.Environment) error {
+	c := self.checkpoint(env)
+	c.Policy.States, c.Policy.Actions, c.Policy.Q = self.states, self.actions, self.Q
+	c.Policy.Params = map[string]float64{
+		"rho":          self.rho,
+		"steps":        float64(self.steps),
+		"window_steps": float64(self.window.Steps),
+		"window_sum":   self.window.Sum,
+	}
+	c.E = self.E
+	return WriteCheckpoint(filename, c)
+}
+
+// Restore the training state saved by SaveCheckpoint. Must be called after
+// Init; a subsequent call to Learn continues from the epoch following the
+// checkpoint.
+func (self *RLearning) LoadCheckpoint(filename string, env environment.Environment) error {
+	if err := self.tabular.LoadCheckpoint(filename, env); err != nil {
+		return err
+	}
+	c, err := ReadCheckpoint(filename)
+	if err != nil {
+		return err
+	}
+	rho, ok := c.Policy.Params["rho"]
+	if !ok {
+		return fmt.Errorf("error loading checkpoint from '%v': no average reward", filename)
+	}
+	self.rho, self.steps = rho, uint(c.Policy.Params["steps"])
+	self.window.Steps, self.window.Sum = uint(c.Policy.Params["window_steps"]), c.Policy.Params["window_sum"]
+	return nil
+}
+
+// Learn the Q-values and average reward
+func (self *RLearning) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		self.DiscretizeState(&s)
+		stats := metrics.NewEpisodeStats(1.0)
+		outcome := metrics.Timeout
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+
+			// select an action
+			aIndex, _, _ := self.EpsilonGreedyAction(s, self.epsilon)
+			a := self.actions[aIndex]
+			_, valueOfS := self.ArgmaxAction(s)
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, a)
+			self.DiscretizeState(&sp)
+
+			// calculate the relative value of the next state, unless the
+			// episode has ended
+			valueOfSP := 0.0
+			if !env.AtGoalState(sp) && !env.AtFailState(sp) {
+				_, valueOfSP = self.ArgmaxAction(sp)
+			}
+
+			// calculate error, and update the policy and average reward
+			delta := self.update(s, a, reward, valueOfS, valueOfSP)
+
+			// iterate the policy
+			s = sp
+			stats.Step(reward, delta)
+			self.steps++
+			if average, done := self.window.Step(reward); done {
+				rec := &metrics.WindowRecord{Step: self.steps, Epoch: epoch, Steps: self.window.Size,
+					AverageReward: average, Estimate: self.rho}
+				if err = monitor.EndWindow(rec); err != nil {
+					return
+				}
+			}
+		}
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// update the Q-values after taking action a in state s, whose greedy value
+// was valueOfS, and receiving reward on the way to a state of relative value
+// valueOfSP. The average reward is only updated if the action was greedy, as
+// exploratory actions say nothing of the greedy policy's reward. Returns the
+// TD error of the pair.
+func (self *RLearning) update(s space.State, a space.Action, reward, valueOfS, valueOfSP float64) float64 {
+	greedy := self.Q[s.Id][a.Id] >= valueOfS
+	delta := reward - self.rho + valueOfSP - self.Q[s.Id][a.Id]
+	self.Q[s.Id][a.Id] += self.alpha * delta
+	if greedy {
+		self.rho += self.beta * (reward - self.rho + valueOfSP - valueOfS)
+	}
+	return delta
+}
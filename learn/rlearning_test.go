@@ -0,0 +1,158 @@
+package learn
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// an environment recording the rewards it gives and counting its resets
+type recordingEnv struct {
+	environment.Environment
+	rewards []float64
+	resets  int
+}
+
+func (env *recordingEnv) ApplyAction(s space.State, a space.Action) (space.State, float64) {
+	sp, reward := env.Environment.ApplyAction(s, a)
+	env.rewards = append(env.rewards, reward)
+	return sp, reward
+}
+
+func (env *recordingEnv) Reset() {
+	env.resets++
+	env.Environment.Reset()
+}
+
+// read the rows of a csv file, less its header
+func readCSV(t *testing.T, filename string) [][]string {
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("Error opening %v: %v\n", filename, err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil || len(rows) == 0 {
+		t.Fatalf("Error reading %v: %v\n", filename, err)
+	}
+	return rows[1:]
+}
+
+func TestRLearningEpisodes(t *testing.T) {
+	const epochs, maxSteps, window = 6, 20, 7
+	cfg, err := config.Parse(strings.NewReader(checkpointTestConfig), "test.cfg")
+	if err != nil {
+		t.Fatalf("Error parsing configuration: %v\n", err)
+	}
+	metricsFile := filepath.Join(t.TempDir(), "metrics.csv")
+	cfg.Set("learning", "learner", "rlearning")
+	cfg.Set("learning", "epochs", strconv.Itoa(epochs))
+	cfg.Set("environment", "max_steps", strconv.Itoa(maxSteps))
+	cfg.Set("learning", "reward_window", strconv.Itoa(window))
+	cfg.Set("metrics", "format", "csv")
+	cfg.Set("metrics", "file", metricsFile)
+	cfg.Set("metrics", "log_interval", "0")
+	env := &recordingEnv{Environment: environment.NewCartPoleEnv(random.NewRand(1))}
+	lrn := NewRLearning(random.NewRand(2))
+	if err := lrn.Init(cfg, env); err != nil {
+		t.Fatalf("Error initializing learner: %v\n", err)
+	}
+	if err := lrn.Learn(env); err != nil {
+		t.Fatalf("Error learning: %v\n", err)
+	}
+
+	// every epoch starts afresh, and ends at the goal, in failure, or at the
+	// step limit
+	if env.resets != epochs {
+		t.Errorf("The environment was reset %v times: expected %v.\n", env.resets, epochs)
+	}
+	episodes := readCSV(t, metricsFile)
+	if len(episodes) != epochs {
+		t.Fatalf("Recorded %v episodes: expected %v.\n", len(episodes), epochs)
+	}
+	var ends []int // the step of training ending each episode
+	total := 0
+	outcomes := make(map[string]bool)
+	for _, ep := range episodes {
+		steps, _ := strconv.Atoi(ep[1])
+		if steps == 0 || steps > maxSteps || (ep[4] == "timeout" && steps < maxSteps) {
+			t.Errorf("Episode %v took %v steps, ending with %v: expected at most %v, and a timeout only at the limit.\n",
+				ep[0], steps, ep[4], maxSteps)
+		}
+		outcomes[ep[4]] = true
+		total += steps
+		ends = append(ends, total)
+	}
+	if !outcomes["fail"] || !outcomes["timeout"] {
+		t.Errorf("Episodes ended with %v: expected both failures and timeouts.\n", outcomes)
+	}
+	if total != len(env.rewards) || lrn.steps != uint(total) {
+		t.Errorf("Episodes took %v steps, the environment %v, and the learner counted %v.\n",
+			total, len(env.rewards), lrn.steps)
+	}
+
+	// the average reward of every window, which run on across episodes
+	windows := readCSV(t, metrics.WindowFile(metricsFile))
+	if len(windows) != total/window {
+		t.Fatalf("Recorded %v windows over %v steps: expected %v.\n", len(windows), total, total/window)
+	}
+	epoch := 0
+	for k, w := range windows {
+		step, _ := strconv.Atoi(w[0])
+		for ends[epoch] < step {
+			epoch++
+		}
+		sum := 0.0
+		for _, r := range env.rewards[k*window : (k+1)*window] {
+			sum += r
+		}
+		average, _ := strconv.ParseFloat(w[3], 64)
+		if step != (k+1)*window || w[1] != strconv.Itoa(epoch+1) || w[2] != strconv.Itoa(window) ||
+			math.Abs(average-sum/window) > 1e-9*math.Abs(sum) {
+			t.Errorf("Window %v = %v: expected step %v in epoch %v, with average reward %v over %v steps.\n",
+				k+1, w, (k+1)*window, epoch+1, sum/window, window)
+		}
+	}
+}
+
+func TestRLearningUpdate(t *testing.T) {
+	s := space.State{Id: 0}
+	tests := []struct {
+		name     string
+		a        uint
+		delta    float64
+		value    float64
+		expected float64 // rho after the update
+	}{
+		// the greedy action's error, 1 - 0.5 + 2 - 3, moves rho by beta
+		// times itself
+		{"greedy", 1, -0.5, 2.75, 0.375},
+		// an exploratory action is updated, but leaves rho alone
+		{"exploratory", 0, 1.5, 1.75, 0.5},
+	}
+	for _, tt := range tests {
+		lrn := NewRLearning(random.NewRand(1))
+		lrn.alpha, lrn.beta, lrn.rho = 0.5, 0.25, 0.5
+		lrn.Q = [][]float64{{1.0, 3.0}}
+		a := space.Action{Id: tt.a}
+		if delta := lrn.update(s, a, 1.0, 3.0, 2.0); delta != tt.delta {
+			t.Errorf("%v: TD error = %v, expected %v\n", tt.name, delta, tt.delta)
+		}
+		if v := lrn.Q[s.Id][a.Id]; v != tt.value {
+			t.Errorf("%v: Q[s][a] = %v, expected %v\n", tt.name, v, tt.value)
+		}
+		if lrn.rho != tt.expected {
+			t.Errorf("%v: rho = %v, expected %v\n", tt.name, lrn.rho, tt.expected)
+		}
+	}
+}
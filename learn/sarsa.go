@@ -0,0 +1,77 @@
+package learn
+
+import (
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/metrics"
+	"github.com/deong/gorl/random"
+)
+
+// Sarsa(lambda): on-policy control over a lattice of states, bootstrapping
+// from the action the epsilon-greedy policy actually takes next
+type Sarsa struct {
+	tabular
+}
+
+// return a Sarsa(lambda) learner drawing random numbers from rng
+func NewSarsa(rng *random.Rand) *Sarsa {
+	return &Sarsa{tabular{episodic: episodic{name: "sarsa", rng: rng}}}
+}
+
+// Initialize the Q-values table and trace.
+func (self *Sarsa) Init(cfg *config.Config, env environment.Environment) error {
+	return self.init(cfg, env)
+}
+
+// Learn the Q-values
+func (self *Sarsa) Learn(env environment.Environment) (err error) {
+	// a run resumed from a checkpoint adds to the metrics already recorded
+	monitor, err := metrics.NewMonitor(self.cfg, self.epoch > 0)
+	if err != nil {
+		return
+	}
+	defer closeOnReturn(monitor, &err)
+
+	for epoch := self.epoch + 1; epoch <= self.maxEpochs; epoch++ {
+		env.Reset()
+		s := env.StartState()
+		self.DiscretizeState(&s)
+		self.clearTrace()
+		stats := metrics.NewEpisodeStats(self.gamma)
+		outcome := metrics.Timeout
+
+		// the first action is chosen before entering the loop, and each
+		// later one when its value is needed for the update
+		aIndex, _, _ := self.EpsilonGreedyAction(s, self.epsilon)
+		for {
+			var over bool
+			if over, outcome = self.episodeOver(env, s, stats.Steps); over {
+				break
+			}
+			a := self.actions[aIndex]
+
+			// observe reward, next state
+			sp, reward := env.ApplyAction(s, a)
+			self.DiscretizeState(&sp)
+
+			// choose the next action, and bootstrap from its value unless
+			// the episode has ended
+			apIndex, qAP, _ := self.EpsilonGreedyAction(sp, self.epsilon)
+			delta := reward - self.Q[s.Id][a.Id]
+			if !env.AtGoalState(sp) && !env.AtFailState(sp) {
+				delta += self.gamma * qAP
+			}
+
+			// mark the visited pair in the trace and update the policy
+			self.visit(s, a)
+			self.traceUpdate(delta)
+
+			s, aIndex = sp, apIndex
+			stats.Step(reward, delta)
+		}
+		if err = self.endEpoch(epoch, stats, outcome, env, monitor, self); err != nil {
+			return
+		}
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package learn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/space"
+)
+
+// a state and action, by id
+type pair struct {
+	s, a uint
+}
+
+// The state shared by the learners that keep a table of action values over
+// a lattice of states, along with the trace and learning parameters most of
+// them use. Learners embed a tabular and implement Learn themselves.
+type tabular struct {
+	episodic
+	states    []space.State
+	actions   []space.Action
+	Q         [][]float64
+	E         [][]float64
+	active    []pair                 // the pairs whose trace is nonzero, in no particular order
+	tables    map[string][][]float64 // further tables of action values kept by some learners
+	alpha     float64
+	gamma     float64
+	lambda    float64
+	trace     string  // how a visit marks the trace: accumulating, replacing, or dutch
+	threshold float64 // trace entries decaying to this or below are dropped
+}
+
+// Build the state and action spaces, initialize the Q-values table and
+// trace, and read the learning parameters.
+func (self *tabular) init(cfg *config.Config, env environment.Environment) (err error) {
+	if err = self.initEpisodes(cfg); err != nil {
+		return
+	}
+
+	// create the state and action spaces
+	if self.states, err = space.BuildStateSpace(cfg, env.Features()); err != nil {
+		return
+	}
+	if self.actions, err = space.BuildActionSpace(cfg, env.ActionRange()); err != nil {
+		return
+	}
+	numStates, numActions := len(self.states), len(self.actions)
+
+	// initialize the Q-values array and execution trace
+	self.Q = make([][]float64, numStates)
+	self.E = make([][]float64, numStates)
+	for i := 0; i < numStates; i++ {
+		self.Q[i] = make([]float64, numActions)
+		self.E[i] = make([]float64, numActions)
+	}
+
+	// set up some learning parameters
+	if self.alpha, err = cfg.Float64("learning", "alpha"); err != nil {
+		return
+	}
+	if self.gamma, err = cfg.Float64("learning", "gamma"); err != nil {
+		return
+	}
+	if self.lambda, err = cfg.Float64("learning", "lambda"); err != nil {
+		return
+	}
+	if self.trace, err = cfg.String("learning", "trace"); err != nil {
+		return
+	}
+	if self.trace != "accumulating" && self.trace != "replacing" && self.trace != "dutch" {
+		return &config.ParamError{Section: "learning", Key: "trace",
+			Err: fmt.Errorf("unknown trace '%v' (expected accumulating, replacing, or dutch)", self.trace)}
+	}
+	if self.threshold, err = cfg.Float64("learning", "trace_threshold"); err != nil {
+		return
+	}
+	self.active = nil
+	return nil
+}
+
+// Return the index of the best action from a given state
+func (self *tabular) ArgmaxAction(s space.State) (indexOfBest uint, valueOfBest float64) {
+	indexOfBest = 0
+	valueOfBest = self.Q[s.Id][0]
+	for i := 1; i < len(self.Q[s.Id]); i++ {
+		if self.Q[s.Id][i] > valueOfBest {
+			indexOfBest, valueOfBest = uint(i), self.Q[s.Id][i]
+		}
+	}
+	return
+}
+
+// Return a random action and its estimated value
+func (self *tabular) RandomAction(s space.State) (indexOfBest uint, valueOfBest float64) {
+	indexOfBest = uint(self.rng.Intn(len(self.Q[s.Id])))
+	valueOfBest = self.Q[s.Id][indexOfBest]
+	return
+}
+
+// Return an epsilon-greedy action, its estimated value, and whether it was chosen greedily
+func (self *tabular) EpsilonGreedyAction(s space.State, epsilon float64) (indexOfBest uint, valueOfBest float64, wasGreedy bool) {
+	if self.rng.Float64() < epsilon {
+		indexOfBest, valueOfBest = self.RandomAction(s)
+		wasGreedy = false
+	} else {
+		indexOfBest, valueOfBest = self.ArgmaxAction(s)
+		wasGreedy = true
+	}
+	return
+}
+
+// given an arbitrary state vector, set its id to that of the nearest state in the space
+func (self *tabular) DiscretizeState(s *space.State) {
+	s.Id = space.NearestState(self.states, s)
+}
+
+// Return the set of actions the learner chooses from
+func (self *tabular) Actions() []space.Action {
+	return self.actions
+}
+
+// Return the greedy action for an arbitrary continuous state
+func (self *tabular) GreedyAction(s space.State) space.Action {
+	self.DiscretizeState(&s)
+	aIndex, _ := self.ArgmaxAction(s)
+	return self.actions[aIndex]
+}
+
+// Save the learned state lattice, actions, and Q-values to a file
+func (self *tabular) SavePolicy(filename string) error {
+	p := &Policy{
+		Learner: self.name,
+		States:  self.states,
+		Actions: self.actions,
+		Q:       self.Q,
+		Tables:  self.tables,
+		Params: map[string]float64{
+			"alpha":   self.alpha,
+			"gamma":   self.gamma,
+			"lambda":  self.lambda,
+			"epsilon": self.epsilon,
+		},
+	}
+	return WritePolicy(filename, p)
+}
+
+// Replace the state lattice, actions, and Q-values with those from a saved
+// policy. Must be called after Init; the learning parameters from the
+// configuration file are left untouched.
+func (self *tabular) LoadPolicy(filename string) error {
+	p, err := ReadPolicy(filename)
+	if err != nil {
+		return err
+	}
+	if err = p.CheckFeatures(len(self.states[0].Vals)); err != nil {
+		return fmt.Errorf("error loading policy from '%v': %v", filename, err)
+	}
+	self.states, self.actions, self.Q = p.States, p.Actions, p.Q
+	self.E = make([][]float64, len(self.Q))
+	for i := range self.E {
+		self.E[i] = make([]float64, len(self.actions))
+	}
+	self.active = nil
+	self.loadTables(p)
+	return nil
+}
+
+// replace the further tables with those of a policy. A table the policy does
+// not have (e.g., when it was saved by a learner keeping only Q) starts at
+// zero.
+func (self *tabular) loadTables(p *Policy) {
+	for name := range self.tables {
+		if table, ok := p.Tables[name]; ok {
+			self.tables[name] = table
+			continue
+		}
+		table := make([][]float64, len(self.Q))
+		for i := range table {
+			table[i] = make([]float64, len(self.Q[i]))
+		}
+		self.tables[name] = table
+	}
+}
+
+// Save the complete training state so that learning can be resumed later
+func (self *tabular) SaveCheckpoint(filename string, env environment.Environment) error {
+	return WriteCheckpoint(filename, self.tableCheckpoint(env))
+}
+
+// return a checkpoint of the tables and trace, to which learners keeping
+// further training state add their own before writing it
+func (self *tabular) tableCheckpoint(env environment.Environment) *Checkpoint {
+	c := self.checkpoint(env)
+	c.Policy.States, c.Policy.Actions, c.Policy.Q, c.Policy.Tables = self.states, self.actions, self.Q, self.tables
+	c.E = self.E
+	return c
+}
+
+// Restore the training state saved by SaveCheckpoint. Must be called after
+// Init; a subsequent call to Learn continues from the epoch following the
+// checkpoint.
+func (self *tabular) LoadCheckpoint(filename string, env environment.Environment) error {
+	c, err := self.loadTableCheckpoint(filename)
+	if err != nil {
+		return err
+	}
+	self.resume(c, env)
+	return nil
+}
+
+// read a checkpoint saved by a learner of the same kind and restore the
+// tables and trace from it, returning it so that learners keeping further
+// training state can restore their own before resuming
+func (self *tabular) loadTableCheckpoint(filename string) (*Checkpoint, error) {
+	c, err := self.readCheckpoint(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.Policy.CheckFeatures(len(self.states[0].Vals)); err != nil {
+		return nil, fmt.Errorf("error loading checkpoint from '%v': %v", filename, err)
+	}
+	if c.E == nil {
+		return nil, fmt.Errorf("error loading checkpoint from '%v': no eligibility trace", filename)
+	}
+	self.states, self.actions, self.Q, self.E = c.Policy.States, c.Policy.Actions, c.Policy.Q, c.E
+	self.activateTrace()
+	self.loadTables(&c.Policy)
+	return c, nil
+}
+
+func (self *tabular) FollowPolicy(env environment.Environment) {
+	self.follow(env, self.GreedyAction)
+}
+
+// clear the trace, as at the start of an episode
+func (self *tabular) clearTrace() {
+	for _, p := range self.active {
+		self.E[p.s][p.a] = 0.0
+	}
+	self.active = self.active[:0]
+}
+
+// rebuild the active set from a trace read from a checkpoint
+func (self *tabular) activateTrace() {
+	self.active = nil
+	for i := range self.E {
+		for j := range self.E[i] {
+			if self.E[i][j] != 0.0 {
+				self.active = append(self.active, pair{uint(i), uint(j)})
+			}
+		}
+	}
+}
+
+// mark a visit to the pair (s, a) in the trace. An accumulating trace adds
+// one to the pair's trace, a replacing trace sets it to one, and a dutch trace
+// (van Seijen and Sutton, 2014) moves it a step of size alpha towards one.
+func (self *tabular) visit(s space.State, a space.Action) {
+	if self.E[s.Id][a.Id] == 0.0 {
+		self.active = append(self.active, pair{s.Id, a.Id})
+	}
+	switch self.trace {
+	case "replacing":
+		self.E[s.Id][a.Id] = 1.0
+	case "dutch":
+		self.E[s.Id][a.Id] = (1.0-self.alpha)*self.E[s.Id][a.Id] + 1.0
+	default:
+		self.E[s.Id][a.Id] += 1.0
+	}
+}
+
+// update every action value by alpha * delta times its trace, then decay the
+// trace by gamma * lambda. Only the active pairs are visited, so the cost
+// grows with the length of the trace rather than the size of the table;
+// pairs whose trace decays to the threshold or below in magnitude are dropped.
+func (self *tabular) traceUpdate(delta float64) {
+	for k := 0; k < len(self.active); {
+		p := self.active[k]
+		self.Q[p.s][p.a] += self.alpha * delta * self.E[p.s][p.a]
+		self.E[p.s][p.a] *= self.gamma * self.lambda
+		if math.Abs(self.E[p.s][p.a]) <= self.threshold {
+			self.E[p.s][p.a] = 0.0
+			last := len(self.active) - 1
+			self.active[k] = self.active[last]
+			self.active = self.active[:last]
+			continue
+		}
+		k++
+	}
+}
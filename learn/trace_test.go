@@ -0,0 +1,139 @@
+package learn
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+func TestVisit(t *testing.T) {
+	tests := []struct {
+		trace    string
+		expected float64
+	}{
+		{"accumulating", 1.5},
+		{"replacing", 1.0},
+		{"dutch", 0.75*0.5 + 1.0},
+	}
+	s, a := space.State{Id: 0}, space.Action{Id: 1}
+	for _, tt := range tests {
+		lrn := &tabular{episodic: episodic{rng: random.NewRand(1)}, alpha: 0.25, trace: tt.trace}
+		lrn.E = [][]float64{{0.0, 0.5}}
+		lrn.visit(s, a)
+		if math.Abs(lrn.E[0][1]-tt.expected) > 1e-12 || lrn.E[0][0] != 0.0 {
+			t.Errorf("%v trace after a visit = %v: expected [0 %v]\n", tt.trace, lrn.E[0], tt.expected)
+		}
+	}
+}
+
+func TestTraceUpdate(t *testing.T) {
+	lrn := &tabular{alpha: 0.5, gamma: 0.9, lambda: 0.5, trace: "replacing", threshold: 0.1}
+	lrn.Q = [][]float64{{1.0, 2.0}}
+	lrn.E = [][]float64{{0.0, 0.0}}
+	lrn.visit(space.State{Id: 0}, space.Action{Id: 0})
+	lrn.traceUpdate(2.0)
+	if lrn.Q[0][0] != 2.0 || lrn.Q[0][1] != 2.0 {
+		t.Errorf("Q after update = %v: expected [2 2]\n", lrn.Q[0])
+	}
+	if math.Abs(lrn.E[0][0]-0.45) > 1e-12 || len(lrn.active) != 1 {
+		t.Errorf("Trace after update = %v with %v active pairs: expected [0.45 0] with 1\n", lrn.E[0], len(lrn.active))
+	}
+
+	// the entry decays to 0.2025, then to 0.091125, below the threshold
+	lrn.traceUpdate(0.0)
+	lrn.traceUpdate(0.0)
+	if lrn.E[0][0] != 0.0 || len(lrn.active) != 0 {
+		t.Errorf("Trace after decaying below the threshold = %v with %v active pairs: expected [0 0] with none\n",
+			lrn.E[0], len(lrn.active))
+	}
+}
+
+func TestQLambdaVariants(t *testing.T) {
+	tests := []struct {
+		peng   bool
+		a      uint
+		q00    float64
+		e00    float64
+		q1a    float64
+		reason string
+	}{
+		// an exploratory action cuts Watkins's trace before the update
+		{false, 1, 0.0, 0.0, 1.0, "Watkins after an exploratory action"},
+		// a greedy action keeps it: the earlier pair is backed up by the
+		// TD error of 1 and its trace decays from 0.45 to 0.2025
+		{false, 0, 0.225, 0.2025, 1.5, "Watkins after a greedy action"},
+		// Peng's trace is never cut: the earlier pair is backed up through
+		// the greedy value of state 1 (an error of 2 - 1), while the visited
+		// pair takes its one-step update
+		{true, 1, 0.225, 0.2025, 1.0, "Peng after an exploratory action"},
+	}
+	for _, tt := range tests {
+		lrn := &QLearning{tabular: tabular{alpha: 0.5, gamma: 0.9, lambda: 0.5, trace: "replacing"}, peng: tt.peng}
+		lrn.Q = [][]float64{{0.0, 0.0}, {1.0, 0.0}}
+		lrn.E = [][]float64{{0.0, 0.0}, {0.0, 0.0}}
+
+		// pair (0, 0) was visited on the step before, leaving a trace of 0.45
+		lrn.visit(space.State{Id: 0}, space.Action{Id: 0})
+		lrn.traceUpdate(0.0)
+
+		// then action a is taken in state 1, whose greedy value is 1, with
+		// a one-step target of 2
+		lrn.update(space.State{Id: 1}, space.Action{Id: tt.a}, 2.0, 1.0)
+		if math.Abs(lrn.Q[0][0]-tt.q00) > 1e-12 || math.Abs(lrn.E[0][0]-tt.e00) > 1e-12 {
+			t.Errorf("%v: Q and trace of the earlier pair = %v and %v, expected %v and %v\n",
+				tt.reason, lrn.Q[0][0], lrn.E[0][0], tt.q00, tt.e00)
+		}
+		if math.Abs(lrn.Q[1][tt.a]-tt.q1a) > 1e-12 {
+			t.Errorf("%v: Q of the visited pair = %v, expected %v\n", tt.reason, lrn.Q[1][tt.a], tt.q1a)
+		}
+	}
+}
+
+// a table the size of the cart pole problem with state_grid = 10 10 10 10 and
+// five actions, with a trace of the length reached after a few hundred steps
+// with gamma * lambda = 0.891 and the default threshold. The benchmarks go on
+// visiting pairs at random, which keeps the length of the trace steady.
+func benchmarkTabular(b *testing.B) (*tabular, *random.Rand) {
+	const numStates, numActions = 10000, 5
+	lrn := &tabular{alpha: 0.1, gamma: 0.99, lambda: 0.9, trace: "accumulating", threshold: 0.0001}
+	lrn.Q = make([][]float64, numStates)
+	lrn.E = make([][]float64, numStates)
+	for i := range lrn.Q {
+		lrn.Q[i] = make([]float64, numActions)
+		lrn.E[i] = make([]float64, numActions)
+	}
+	rng := random.NewRand(1)
+	for k := 0; k < 300; k++ {
+		lrn.visit(space.State{Id: uint(rng.Intn(numStates))}, space.Action{Id: uint(rng.Intn(numActions))})
+		lrn.traceUpdate(0.0)
+	}
+	return lrn, rng
+}
+
+// one step's update of the trace, as it was done before the active set was
+// kept: every entry of the table is visited
+func BenchmarkTraceUpdateDense(b *testing.B) {
+	lrn, rng := benchmarkTabular(b)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		lrn.E[rng.Intn(len(lrn.Q))][rng.Intn(len(lrn.Q[0]))] += 1.0
+		for i := range lrn.Q {
+			for j := range lrn.Q[i] {
+				lrn.Q[i][j] += lrn.alpha * 0.01 * lrn.E[i][j]
+				lrn.E[i][j] *= lrn.gamma * lrn.lambda
+			}
+		}
+	}
+}
+
+// one step's update of the trace through the active set
+func BenchmarkTraceUpdateSparse(b *testing.B) {
+	lrn, rng := benchmarkTabular(b)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		lrn.visit(space.State{Id: uint(rng.Intn(len(lrn.Q)))}, space.Action{Id: uint(rng.Intn(len(lrn.Q[0])))})
+		lrn.traceUpdate(0.01)
+	}
+}
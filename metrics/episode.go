@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/space"
+)
+
+// The way in which an episode ended
+type Outcome int
+
+const (
+	Timeout Outcome = iota // the step limit was reached
+	Goal                   // the environment reached a goal state
+	Fail                   // the environment reached a fail state
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Goal:
+		return "goal"
+	case Fail:
+		return "fail"
+	}
+	return "timeout"
+}
+
+// Summary of a single episode
+type Episode struct {
+	Steps   uint
+	Return  float64
+	Outcome Outcome
+}
+
+// Accumulates the statistics of a training episode as it runs
+type EpisodeStats struct {
+	Episode
+	DiscountedReturn float64
+
+	gamma    float64
+	discount float64
+	sumAbsTD float64
+	start    time.Time
+}
+
+// start tracking an episode whose rewards are discounted by gamma
+func NewEpisodeStats(gamma float64) *EpisodeStats {
+	return &EpisodeStats{gamma: gamma, discount: 1.0, start: time.Now()}
+}
+
+// account for one step with the given reward and temporal-difference error
+func (es *EpisodeStats) Step(reward, tdError float64) {
+	es.Steps++
+	es.Return += reward
+	es.DiscountedReturn += es.discount * reward
+	es.discount *= es.gamma
+	es.sumAbsTD += math.Abs(tdError)
+}
+
+// finish the episode, returning the record to send to a metrics sink
+func (es *EpisodeStats) Record(epoch uint, outcome Outcome, epsilon float64) *EpisodeRecord {
+	es.Outcome = outcome
+	rec := &EpisodeRecord{
+		Epoch:            epoch,
+		Steps:            es.Steps,
+		Return:           es.Return,
+		DiscountedReturn: es.DiscountedReturn,
+		Outcome:          outcome.String(),
+		Epsilon:          epsilon,
+		WallTime:         time.Since(es.start).Seconds(),
+	}
+	if es.Steps > 0 {
+		rec.MeanAbsTDError = es.sumAbsTD / float64(es.Steps)
+	}
+	return rec
+}
+
+// Accumulates the rewards of successive windows of steps, which measure the
+// progress of average-reward learners better than episodes do, as the
+// windows run on across the ends of episodes
+type RewardWindow struct {
+	Size  uint
+	Steps uint    // steps taken so far in the current window
+	Sum   float64 // rewards received so far in the current window
+}
+
+// account for one step with the given reward, returning the average reward
+// of the window and true when the step completes it
+func (w *RewardWindow) Step(reward float64) (float64, bool) {
+	if w.Size == 0 {
+		return 0.0, false
+	}
+	w.Steps++
+	w.Sum += reward
+	if w.Steps < w.Size {
+		return 0.0, false
+	}
+	average := w.Sum / float64(w.Steps)
+	w.Steps, w.Sum = 0, 0.0
+	return average, true
+}
+
+// the step limit for episodes run outside of training when max_steps is zero
+const DefaultMaxSteps = 10000
+
+// return the step limit for episodes run outside of training: max_steps from
+// the configuration, unless it is zero, since an untrained policy may never
+// reach a goal or fail state
+func EpisodeLimit(cfg *config.Config) uint {
+	if n, err := cfg.Uint("environment", "max_steps"); err == nil && n > 0 {
+		return n
+	}
+	return DefaultMaxSteps
+}
+
+// Run one episode choosing actions with the given policy, stopping at a goal
+// or fail state or after maxSteps steps. If trace is not nil, each step is
+// written to it.
+func RunEpisode(env environment.Environment, policy func(s space.State) space.Action, maxSteps uint, trace io.Writer) (ep Episode) {
+	env.Reset()
+	s := env.StartState()
+	for {
+		if env.AtGoalState(s) {
+			ep.Outcome = Goal
+			break
+		} else if env.AtFailState(s) {
+			ep.Outcome = Fail
+			break
+		} else if ep.Steps >= maxSteps {
+			ep.Outcome = Timeout
+			break
+		}
+		a := policy(s)
+		sp, reward := env.ApplyAction(s, a)
+		ep.Steps++
+		ep.Return += reward
+		if trace != nil {
+			fmt.Fprintf(trace, "step %d: state %v action %v reward %v\n", ep.Steps, s.Vals, a.Val, reward)
+		}
+		s = sp
+	}
+	return
+}
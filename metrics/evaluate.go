@@ -0,0 +1,243 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+	"github.com/deong/gorl/random"
+	"github.com/deong/gorl/space"
+)
+
+// Summary statistics of a sample
+type Summary struct {
+	Mean   float64
+	Median float64
+	Std    float64
+	Min    float64
+	Max    float64
+}
+
+// compute the summary statistics of a non-empty sample. Std is the sample
+// standard deviation (zero for a single observation).
+func Summarize(xs []float64) (sum Summary) {
+	sorted := make([]float64, len(xs))
+	copy(sorted, xs)
+	sort.Float64s(sorted)
+	n := len(sorted)
+
+	sum.Min, sum.Max = sorted[0], sorted[n-1]
+	if n%2 == 1 {
+		sum.Median = sorted[n/2]
+	} else {
+		sum.Median = (sorted[n/2-1] + sorted[n/2]) / 2.0
+	}
+	for _, x := range sorted {
+		sum.Mean += x
+	}
+	sum.Mean /= float64(n)
+	if n > 1 {
+		for _, x := range sorted {
+			sum.Std += (x - sum.Mean) * (x - sum.Mean)
+		}
+		sum.Std = math.Sqrt(sum.Std / float64(n-1))
+	}
+	return
+}
+
+func (sum Summary) String() string {
+	return fmt.Sprintf("mean %.4g, median %.4g, std %.4g, min %.4g, max %.4g",
+		sum.Mean, sum.Median, sum.Std, sum.Min, sum.Max)
+}
+
+// The results of evaluating a policy over a number of episodes
+type EvalSummary struct {
+	Episodes    uint
+	Return      Summary
+	Length      Summary
+	SuccessRate float64 // fraction of episodes ending at a goal state
+	FailureRate float64 // fraction of episodes ending at a fail state
+}
+
+func (es EvalSummary) String() string {
+	return fmt.Sprintf("%v episodes\n  return: %v\n  length: %v\n  success rate %.3f, failure rate %.3f, timeout rate %.3f",
+		es.Episodes, es.Return, es.Length, es.SuccessRate, es.FailureRate, 1.0-es.SuccessRate-es.FailureRate)
+}
+
+// The part of a learner needed to follow its policy. Learners with a discrete
+// set of actions return them from Actions, so that evaluation can explore
+// among them; others return nil.
+type Agent interface {
+	Actions() []space.Action
+	GreedyAction(s space.State) space.Action
+}
+
+// An Evaluator runs a learner's current policy for a number of episodes
+// without learning, optionally at regular intervals during training. Each
+// episode is run from its own seed, derived from the evaluator's seed, so
+// successive evaluations see the same set of start states.
+type Evaluator struct {
+	Episodes uint
+	Epsilon  float64 // probability of a uniformly random action
+	MaxSteps uint
+	Seed     int64
+	Interval uint      // evaluate every interval epochs during training; 0 for never
+	Log      io.Writer // if not nil, receives one line per episode
+	out      io.Writer // receives the summaries of periodic evaluations
+
+	f *os.File // optional file receiving one row per periodic evaluation
+	w *csv.Writer
+}
+
+var evalRecordHeader = []string{"epoch", "episodes",
+	"return_mean", "return_median", "return_std", "return_min", "return_max",
+	"length_mean", "length_median", "length_std", "length_min", "length_max",
+	"success_rate", "failure_rate"}
+
+// return an evaluator configured by the [evaluation] section of the
+// configuration. When appending (e.g., when resuming from a checkpoint),
+// periodic results are added to the end of an existing file rather than
+// replacing it.
+func CreateEvaluator(cfg *config.Config, appending bool) (ev *Evaluator, err error) {
+	ev = &Evaluator{MaxSteps: EpisodeLimit(cfg), out: os.Stdout}
+	if ev.Episodes, err = cfg.Uint("evaluation", "episodes"); err != nil {
+		return nil, err
+	} else if ev.Episodes == 0 {
+		return nil, &config.ParamError{Section: "evaluation", Key: "episodes", Err: fmt.Errorf("at least one episode is needed")}
+	}
+	if ev.Epsilon, err = cfg.Float64("evaluation", "epsilon"); err != nil {
+		return nil, err
+	}
+	if n, err := cfg.Uint("evaluation", "max_steps"); err == nil && n > 0 {
+		ev.MaxSteps = n
+	} else if err != nil && !config.IsMissing(err) {
+		return nil, err
+	}
+	if ev.Interval, err = cfg.Uint("evaluation", "interval"); err != nil {
+		return nil, err
+	}
+	if s, err := cfg.Int("evaluation", "seed"); err == nil {
+		ev.Seed = int64(s)
+	} else if config.IsMissing(err) {
+		seed, _, err := random.RunSeed(cfg)
+		if err != nil {
+			return nil, err
+		}
+		ev.Seed = random.StreamSeed(seed, random.EvaluationStream)
+	} else {
+		return nil, err
+	}
+
+	filename, ferr := cfg.String("evaluation", "file")
+	if ferr != nil || ev.Interval == 0 {
+		return ev, nil
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appending {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	if ev.f, err = os.OpenFile(filename, flags, 0644); err != nil {
+		return nil, fmt.Errorf("error opening evaluation file: %v", err)
+	}
+	ev.w = csv.NewWriter(ev.f)
+	if info, err := ev.f.Stat(); err == nil && info.Size() == 0 {
+		ev.w.Write(evalRecordHeader)
+	}
+	return ev, nil
+}
+
+// run the evaluation episodes and summarize them. The generator of a
+// Stochastic environment is restored afterwards, so evaluating during
+// training does not change the course of training.
+func (ev *Evaluator) Evaluate(env environment.Environment, lrn Agent) EvalSummary {
+	if st, ok := env.(environment.Stochastic); ok {
+		saved := st.Rng().State()
+		defer st.Rng().SetState(saved)
+	}
+
+	rng := random.NewRand(0)
+	actions := lrn.Actions()
+	actionRange := env.ActionRange()
+	policy := func(s space.State) space.Action {
+		if ev.Epsilon > 0 && rng.Float64() < ev.Epsilon {
+			if len(actions) == 0 {
+				return space.Action{Id: 0, Val: actionRange.Min + rng.Float64()*(actionRange.Max-actionRange.Min)}
+			}
+			return actions[rng.Intn(len(actions))]
+		}
+		return lrn.GreedyAction(s)
+	}
+
+	returns := make([]float64, ev.Episodes)
+	lengths := make([]float64, ev.Episodes)
+	var goals, fails uint
+	for i := uint(0); i < ev.Episodes; i++ {
+		episodeSeed := random.StreamSeed(ev.Seed, uint64(i))
+		if st, ok := env.(environment.Stochastic); ok {
+			st.Rng().SetState(uint64(random.StreamSeed(episodeSeed, random.EnvironmentStream)))
+		}
+		rng.SetState(uint64(random.StreamSeed(episodeSeed, random.LearnerStream)))
+
+		ep := RunEpisode(env, policy, ev.MaxSteps, nil)
+		returns[i], lengths[i] = ep.Return, float64(ep.Steps)
+		if ep.Outcome == Goal {
+			goals++
+		} else if ep.Outcome == Fail {
+			fails++
+		}
+		if ev.Log != nil {
+			fmt.Fprintf(ev.Log, "episode %d: %v steps, return %v (%v)\n", i+1, ep.Steps, ep.Return, ep.Outcome)
+		}
+	}
+	return EvalSummary{
+		Episodes:    ev.Episodes,
+		Return:      Summarize(returns),
+		Length:      Summarize(lengths),
+		SuccessRate: float64(goals) / float64(ev.Episodes),
+		FailureRate: float64(fails) / float64(ev.Episodes),
+	}
+}
+
+// Called by learners at the end of each training epoch: if a periodic
+// evaluation is due, run it, print the summary, and record it to the
+// evaluation file.
+func (ev *Evaluator) AfterEpoch(epoch uint, env environment.Environment, lrn Agent) error {
+	if ev.Interval == 0 || epoch%ev.Interval != 0 {
+		return nil
+	}
+	es := ev.Evaluate(env, lrn)
+	fmt.Fprintf(ev.out, "Evaluation after epoch %v: mean return %.4g, mean length %.4g, success rate %.3f, failure rate %.3f\n",
+		epoch, es.Return.Mean, es.Length.Mean, es.SuccessRate, es.FailureRate)
+	if ev.w == nil {
+		return nil
+	}
+	g := func(x float64) string { return strconv.FormatFloat(x, 'g', -1, 64) }
+	ev.w.Write([]string{
+		strconv.FormatUint(uint64(epoch), 10),
+		strconv.FormatUint(uint64(es.Episodes), 10),
+		g(es.Return.Mean), g(es.Return.Median), g(es.Return.Std), g(es.Return.Min), g(es.Return.Max),
+		g(es.Length.Mean), g(es.Length.Median), g(es.Length.Std), g(es.Length.Min), g(es.Length.Max),
+		g(es.SuccessRate), g(es.FailureRate),
+	})
+	ev.w.Flush()
+	return ev.w.Error()
+}
+
+// close the evaluation file, if any
+func (ev *Evaluator) Close() error {
+	if ev.f == nil {
+		return nil
+	}
+	ev.w.Flush()
+	if err := ev.w.Error(); err != nil {
+		ev.f.Close()
+		return err
+	}
+	return ev.f.Close()
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+type summarizeTest struct {
+	xs     []float64
+	result Summary
+}
+
+var summarizeTests = []summarizeTest{
+	summarizeTest{[]float64{3.0}, Summary{3.0, 3.0, 0.0, 3.0, 3.0}},
+	summarizeTest{[]float64{4.0, 1.0, 3.0, 2.0}, Summary{2.5, 2.5, 1.290994, 1.0, 4.0}},
+	summarizeTest{[]float64{-2.0, 10.0, 1.0}, Summary{3.0, 1.0, 6.244998, -2.0, 10.0}},
+}
+
+func TestSummarize(t *testing.T) {
+	for _, st := range summarizeTests {
+		sum := Summarize(st.xs)
+		got := []float64{sum.Mean, sum.Median, sum.Std, sum.Min, sum.Max}
+		want := []float64{st.result.Mean, st.result.Median, st.result.Std, st.result.Min, st.result.Max}
+		if !vectorEpsilonEqual(got, want, 0.00001) {
+			t.Errorf("Error: Summarize(%v) = %v, expected %v\n", st.xs, sum, st.result)
+		}
+	}
+}
+
+func vectorEpsilonEqual(v1, v2 []float64, epsilon float64) bool {
+	for i := range v1 {
+		if math.Abs(v1[i]-v2[i]) >= epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRewardWindow(t *testing.T) {
+	w := RewardWindow{Size: 3}
+	var averages []float64
+	for _, r := range []float64{1.0, 2.0, 3.0, -1.0, -1.0, 5.0, 7.0} {
+		if average, done := w.Step(r); done {
+			averages = append(averages, average)
+		}
+	}
+	if expected := []float64{2.0, 1.0}; !vectorEpsilonEqual(averages, expected, 1e-12) || w.Steps != 1 || w.Sum != 7.0 {
+		t.Errorf("Window averages = %v with %v steps summing to %v pending, expected %v with 1 summing to 7\n",
+			averages, w.Steps, w.Sum, expected)
+	}
+	if _, done := (&RewardWindow{}).Step(1.0); done {
+		t.Errorf("A window of size zero completed\n")
+	}
+}
@@ -0,0 +1,322 @@
+// Package metrics records the course of training and evaluates policies over
+// many episodes.
+package metrics
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/deong/gorl/config"
+	"github.com/deong/gorl/environment"
+)
+
+// The statistics recorded for every training episode
+type EpisodeRecord struct {
+	Epoch            uint    `json:"epoch"`
+	Steps            uint    `json:"steps"`
+	Return           float64 `json:"return"`
+	DiscountedReturn float64 `json:"discounted_return"`
+	Outcome          string  `json:"outcome"`
+	Epsilon          float64 `json:"epsilon"`
+	MeanAbsTDError   float64 `json:"mean_abs_td_error"`
+	WallTime         float64 `json:"wall_time"` // seconds spent on the episode
+}
+
+var episodeRecordHeader = []string{"epoch", "steps", "return", "discounted_return", "outcome",
+	"epsilon", "mean_abs_td_error", "wall_time"}
+
+// The average reward over a window of training steps, as reported by
+// average-reward learners, whose windows run on across episodes
+type WindowRecord struct {
+	Step          uint    `json:"step"`  // the step of training ending the window
+	Epoch         uint    `json:"epoch"` // the epoch in which the window ended
+	Steps         uint    `json:"window_steps"`
+	AverageReward float64 `json:"average_reward"`
+	Estimate      float64 `json:"estimate"` // the learner's estimate of the average reward
+}
+
+var windowRecordHeader = []string{"step", "epoch", "window_steps", "average_reward", "estimate"}
+
+// A Sink receives one record per training episode, and one per window of
+// steps from learners that report them
+type Sink interface {
+	Record(rec *EpisodeRecord) error
+	RecordWindow(rec *WindowRecord) error
+	Close() error
+}
+
+// return the name of the file to which the window records of a metrics file
+// are written: its name with _windows added before the extension (e.g.,
+// metrics_windows.csv for metrics.csv). Windows have a file of their own so
+// that the metrics file holds episodes alone.
+func WindowFile(filename string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "_windows" + ext
+}
+
+// return the metrics sink selected by the [metrics] section of the
+// configuration. If no format is configured, records are discarded. When
+// appending (e.g., when resuming from a checkpoint), records are added to the
+// end of an existing file rather than replacing it. The file of window
+// records is only created once a window is recorded.
+func CreateSink(cfg *config.Config, appending bool) (Sink, error) {
+	format, err := cfg.String("metrics", "format")
+	if config.IsMissing(err) || format == "none" {
+		return nullSink{}, nil
+	}
+	var filename string
+	if filename, err = cfg.String("metrics", "file"); err != nil {
+		return nil, fmt.Errorf("metrics format '%v' requires a metrics file: %v", format, err)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if appending {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	switch format {
+	case "csv":
+		episodes, err := openCSV(filename, flags, episodeRecordHeader)
+		if err != nil {
+			return nil, fmt.Errorf("error opening metrics file: %v", err)
+		}
+		return &csvSink{episodes: episodes, windowFile: WindowFile(filename), flags: flags}, nil
+	case "jsonl":
+		episodes, err := openJSONL(filename, flags)
+		if err != nil {
+			return nil, fmt.Errorf("error opening metrics file: %v", err)
+		}
+		return &jsonlSink{episodes: episodes, windowFile: WindowFile(filename), flags: flags}, nil
+	}
+	return nil, &config.ParamError{Section: "metrics", Key: "format", Err: fmt.Errorf("unknown format '%v' (expected csv, jsonl, or none)", format)}
+}
+
+// A Monitor receives the record of every training epoch. It writes the record
+// to the metrics sink, prints a progress line every log_interval epochs, and
+// runs the periodic evaluations.
+type Monitor struct {
+	sink        Sink
+	eval        *Evaluator
+	logInterval uint
+	out         io.Writer
+}
+
+// return a monitor configured by the [metrics] and [evaluation] sections of
+// the configuration. When appending, metrics and evaluations are added to the
+// end of existing files.
+func NewMonitor(cfg *config.Config, appending bool) (m *Monitor, err error) {
+	m = &Monitor{out: os.Stdout}
+	if m.logInterval, err = cfg.Uint("metrics", "log_interval"); err != nil {
+		return nil, err
+	}
+	if m.sink, err = CreateSink(cfg, appending); err != nil {
+		return nil, err
+	}
+	if m.eval, err = CreateEvaluator(cfg, appending); err != nil {
+		m.sink.Close()
+		return nil, err
+	}
+	if m.logInterval == 0 {
+		m.eval.out = io.Discard
+	}
+	return m, nil
+}
+
+// record the end of a training epoch of lrn
+func (m *Monitor) EndEpoch(rec *EpisodeRecord, env environment.Environment, lrn Agent) error {
+	if m.logInterval > 0 && rec.Epoch%m.logInterval == 0 {
+		fmt.Fprintf(m.out, "Epoch: %v -- %v steps, return %v (%v).\n", rec.Epoch, rec.Steps, rec.Return, rec.Outcome)
+	}
+	if err := m.sink.Record(rec); err != nil {
+		return fmt.Errorf("error writing metrics: %v", err)
+	}
+	if err := m.eval.AfterEpoch(rec.Epoch, env, lrn); err != nil {
+		return fmt.Errorf("error writing evaluation: %v", err)
+	}
+	return nil
+}
+
+// record the end of a window of training steps, printing a progress line
+// unless log_interval is zero
+func (m *Monitor) EndWindow(rec *WindowRecord) error {
+	if m.logInterval > 0 {
+		fmt.Fprintf(m.out, "Step: %v -- average reward %v over the last %v steps (estimate %v).\n",
+			rec.Step, rec.AverageReward, rec.Steps, rec.Estimate)
+	}
+	if err := m.sink.RecordWindow(rec); err != nil {
+		return fmt.Errorf("error writing metrics: %v", err)
+	}
+	return nil
+}
+
+// close the metrics and evaluation files
+func (m *Monitor) Close() error {
+	err := m.sink.Close()
+	if eerr := m.eval.Close(); err == nil {
+		err = eerr
+	}
+	return err
+}
+
+// discards all records
+type nullSink struct{}
+
+func (nullSink) Record(_ *EpisodeRecord) error      { return nil }
+func (nullSink) RecordWindow(_ *WindowRecord) error { return nil }
+func (nullSink) Close() error                       { return nil }
+
+// a file of comma-separated values with a header row
+type csvFile struct {
+	f *os.File
+	w *csv.Writer
+}
+
+// open a csv file with the given flags, writing the header if it is empty
+func openCSV(filename string, flags int, header []string) (*csvFile, error) {
+	f, err := os.OpenFile(filename, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	file := &csvFile{f: f, w: csv.NewWriter(f)}
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		file.w.Write(header)
+	}
+	return file, nil
+}
+
+func (file *csvFile) write(row []string) error {
+	file.w.Write(row)
+	// flush every record so that a killed run keeps the records it finished
+	file.w.Flush()
+	return file.w.Error()
+}
+
+func (file *csvFile) Close() error {
+	file.w.Flush()
+	if err := file.w.Error(); err != nil {
+		file.f.Close()
+		return err
+	}
+	return file.f.Close()
+}
+
+// writes records as comma-separated values with a header row, episodes and
+// windows to files of their own
+type csvSink struct {
+	episodes   *csvFile
+	windows    *csvFile // opened at the first window record
+	windowFile string
+	flags      int
+}
+
+// format a value with as few digits as represent it exactly
+func formatFloat(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+func (sink *csvSink) Record(rec *EpisodeRecord) error {
+	return sink.episodes.write([]string{
+		strconv.FormatUint(uint64(rec.Epoch), 10),
+		strconv.FormatUint(uint64(rec.Steps), 10),
+		formatFloat(rec.Return),
+		formatFloat(rec.DiscountedReturn),
+		rec.Outcome,
+		formatFloat(rec.Epsilon),
+		formatFloat(rec.MeanAbsTDError),
+		formatFloat(rec.WallTime),
+	})
+}
+
+func (sink *csvSink) RecordWindow(rec *WindowRecord) (err error) {
+	if sink.windows == nil {
+		if sink.windows, err = openCSV(sink.windowFile, sink.flags, windowRecordHeader); err != nil {
+			return
+		}
+	}
+	return sink.windows.write([]string{
+		strconv.FormatUint(uint64(rec.Step), 10),
+		strconv.FormatUint(uint64(rec.Epoch), 10),
+		strconv.FormatUint(uint64(rec.Steps), 10),
+		formatFloat(rec.AverageReward),
+		formatFloat(rec.Estimate),
+	})
+}
+
+func (sink *csvSink) Close() error {
+	err := sink.episodes.Close()
+	if sink.windows != nil {
+		if werr := sink.windows.Close(); err == nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+// a file of JSON objects, one per line
+type jsonlFile struct {
+	f   *os.File
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func openJSONL(filename string, flags int) (*jsonlFile, error) {
+	f, err := os.OpenFile(filename, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	return &jsonlFile{f: f, w: w, enc: json.NewEncoder(w)}, nil
+}
+
+func (file *jsonlFile) write(rec interface{}) error {
+	if err := file.enc.Encode(rec); err != nil {
+		return err
+	}
+	return file.w.Flush()
+}
+
+func (file *jsonlFile) Close() error {
+	if err := file.w.Flush(); err != nil {
+		file.f.Close()
+		return err
+	}
+	return file.f.Close()
+}
+
+// writes records as one JSON object per line, episodes and windows to files
+// of their own
+type jsonlSink struct {
+	episodes   *jsonlFile
+	windows    *jsonlFile // opened at the first window record
+	windowFile string
+	flags      int
+}
+
+func (sink *jsonlSink) Record(rec *EpisodeRecord) error {
+	return sink.episodes.write(rec)
+}
+
+func (sink *jsonlSink) RecordWindow(rec *WindowRecord) (err error) {
+	if sink.windows == nil {
+		if sink.windows, err = openJSONL(sink.windowFile, sink.flags); err != nil {
+			return
+		}
+	}
+	return sink.windows.write(rec)
+}
+
+func (sink *jsonlSink) Close() error {
+	err := sink.episodes.Close()
+	if sink.windows != nil {
+		if werr := sink.windows.Close(); err == nil {
+			err = werr
+		}
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package nn
+
+import (
+	"math"
+)
+
+// Adam (Kingma and Ba, 2015) keeps running estimates of the mean and
+// uncentred variance of each parameter's gradient, and steps each parameter
+// by its bias-corrected mean over the square root of its variance, so that
+// the step size adapts to the scale of the gradient. The fields are exported
+// so that the optimizer's state can be saved and restored.
+type Adam struct {
+	Rate    float64
+	Beta1   float64
+	Beta2   float64
+	Epsilon float64
+	T       int     // the number of steps taken
+	M, V    []Layer // the estimates of the mean and variance
+}
+
+// return an optimizer for a network's parameters with the given step size
+// and the usual settings of the others
+func NewAdam(net *MLP, rate float64) *Adam {
+	a := &Adam{Rate: rate, Beta1: 0.9, Beta2: 0.999, Epsilon: 1e-8}
+	for _, l := range net.Layers {
+		a.M = append(a.M, newLayer(len(l.W[0]), len(l.B)))
+		a.V = append(a.V, newLayer(len(l.W[0]), len(l.B)))
+	}
+	return a
+}
+
+// Replace the optimizer's state with that saved from another optimizer of
+// the same network.
+func (a *Adam) Load(t int, m, v []Layer) error {
+	if err := checkShape(a.M, m); err != nil {
+		return err
+	}
+	if err := checkShape(a.V, v); err != nil {
+		return err
+	}
+	a.T = t
+	copyLayers(a.M, m)
+	copyLayers(a.V, v)
+	return nil
+}
+
+// Step the network's parameters along the gradients accumulated by Backward,
+// scaled by scale (e.g., to average them over a batch), and reset the
+// gradients to zero.
+func (a *Adam) Step(net *MLP, scale float64) {
+	a.T++
+	c1 := 1.0 - math.Pow(a.Beta1, float64(a.T))
+	c2 := 1.0 - math.Pow(a.Beta2, float64(a.T))
+	update := func(p, g, m, v *float64) {
+		grad := scale * *g
+		*m = a.Beta1**m + (1.0-a.Beta1)*grad
+		*v = a.Beta2**v + (1.0-a.Beta2)*grad*grad
+		*p -= a.Rate * (*m / c1) / (math.Sqrt(*v/c2) + a.Epsilon)
+		*g = 0.0
+	}
+	for k, l := range net.Layers {
+		g, m, v := net.grads[k], a.M[k], a.V[k]
+		for i := range l.W {
+			update(&l.B[i], &g.B[i], &m.B[i], &v.B[i])
+			for j := range l.W[i] {
+				update(&l.W[i][j], &g.W[i][j], &m.W[i][j], &v.W[i][j])
+			}
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Package nn implements the small multilayer perceptrons, and their
+// optimizer, with which learners approximate functions of the state. It is
+// pure Go, meant for networks of a few layers of tens or hundreds of units
+// trained on a CPU.
+package nn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/deong/gorl/random"
+)
+
+// A Layer of a network maps its inputs x to W x + b, followed by the
+// activation in all but the output layer. W has a row per output.
+type Layer struct {
+	W [][]float64
+	B []float64
+}
+
+// return a layer of zeros with the given numbers of inputs and outputs
+func newLayer(in, out int) Layer {
+	l := Layer{W: make([][]float64, out), B: make([]float64, out)}
+	for i := range l.W {
+		l.W[i] = make([]float64, in)
+	}
+	return l
+}
+
+// An activation function and its derivative, given the function's input
+type activation struct {
+	f, df func(z float64) float64
+}
+
+// The activations of hidden layers, by the name used for the activation
+// parameter in the [network] section
+var activations = map[string]activation{
+	"relu": {
+		f: func(z float64) float64 { return math.Max(z, 0.0) },
+		df: func(z float64) float64 {
+			if z > 0.0 {
+				return 1.0
+			}
+			return 0.0
+		},
+	},
+	"tanh": {
+		f: math.Tanh,
+		df: func(z float64) float64 {
+			t := math.Tanh(z)
+			return 1.0 - t*t
+		},
+	},
+}
+
+// An MLP is a fully connected feed-forward network with a linear output
+// layer. Besides its parameters, it keeps the values computed by the last
+// call to Forward, from which Backward accumulates the gradients of the
+// parameters.
+type MLP struct {
+	Layers  []Layer
+	act     activation
+	actName string
+	grads   []Layer
+	in      [][]float64 // the input of each layer, and the output of the network
+	z       [][]float64 // the value of each layer before its activation
+	delta   [][]float64 // the gradient with respect to each layer's z
+}
+
+// return a network with the given numbers of units in each layer, from the
+// inputs to the outputs, and the named activation in its hidden layers. The
+// weights are drawn from rng, scaled by the number of inputs of their layer
+// as suits the activation (He et al., 2015, for relu; Glorot and Bengio,
+// 2010, for tanh), and the biases are zero.
+func NewMLP(sizes []int, act string, rng *random.Rand) (*MLP, error) {
+	net, err := newMLP(sizes, act)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range net.Layers {
+		scale := math.Sqrt(1.0 / float64(len(l.W[0])))
+		if act == "relu" {
+			scale *= math.Sqrt2
+		}
+		for i := range l.W {
+			for j := range l.W[i] {
+				l.W[i][j] = scale * rng.NormFloat64()
+			}
+		}
+	}
+	return net, nil
+}
+
+// return a network of the given shape with all of its parameters zero
+func newMLP(sizes []int, act string) (*MLP, error) {
+	a, ok := activations[act]
+	if !ok {
+		return nil, fmt.Errorf("unknown activation '%v' (expected relu or tanh)", act)
+	}
+	if len(sizes) < 2 {
+		return nil, fmt.Errorf("a network needs at least an input and an output layer")
+	}
+	net := &MLP{act: a, actName: act, in: make([][]float64, len(sizes)), z: make([][]float64, len(sizes)-1),
+		delta: make([][]float64, len(sizes)-1)}
+	for k, n := range sizes {
+		if n < 1 {
+			return nil, fmt.Errorf("layer %v has %v units", k, n)
+		}
+		net.in[k] = make([]float64, n)
+		if k == 0 {
+			continue
+		}
+		net.Layers = append(net.Layers, newLayer(sizes[k-1], n))
+		net.grads = append(net.grads, newLayer(sizes[k-1], n))
+		net.z[k-1] = make([]float64, n)
+		net.delta[k-1] = make([]float64, n)
+	}
+	return net, nil
+}
+
+// return a network of the same shape and activation, and the same parameters
+func (net *MLP) Clone() *MLP {
+	c, _ := newMLP(net.Sizes(), net.actName)
+	c.CopyFrom(net)
+	return c
+}
+
+// return the numbers of units in each layer, from the inputs to the outputs
+func (net *MLP) Sizes() []int {
+	sizes := make([]int, len(net.in))
+	for k := range net.in {
+		sizes[k] = len(net.in[k])
+	}
+	return sizes
+}
+
+// set the parameters to those of a network of the same shape
+func (net *MLP) CopyFrom(other *MLP) {
+	copyLayers(net.Layers, other.Layers)
+}
+
+// Replace the parameters with the given layers, which must match the
+// network's shape.
+func (net *MLP) Load(layers []Layer) error {
+	if err := checkShape(net.Layers, layers); err != nil {
+		return err
+	}
+	copyLayers(net.Layers, layers)
+	return nil
+}
+
+// check that two sets of layers have the same shape
+func checkShape(want, got []Layer) error {
+	if len(got) != len(want) {
+		return fmt.Errorf("%v layers, not %v", len(got), len(want))
+	}
+	for k := range want {
+		if len(got[k].B) != len(want[k].B) || len(got[k].W) != len(want[k].W) {
+			return fmt.Errorf("layer %v has %v outputs, not %v", k+1, len(got[k].W), len(want[k].W))
+		}
+		for i := range want[k].W {
+			if len(got[k].W[i]) != len(want[k].W[i]) {
+				return fmt.Errorf("layer %v has %v inputs, not %v", k+1, len(got[k].W[i]), len(want[k].W[i]))
+			}
+		}
+	}
+	return nil
+}
+
+// copy the parameters of src into dst, of the same shape
+func copyLayers(dst, src []Layer) {
+	for k := range dst {
+		copy(dst[k].B, src[k].B)
+		for i := range dst[k].W {
+			copy(dst[k].W[i], src[k].W[i])
+		}
+	}
+}
+
+// Return the outputs of the network for input x. The slice is reused by the
+// next call.
+func (net *MLP) Forward(x []float64) []float64 {
+	copy(net.in[0], x)
+	last := len(net.Layers) - 1
+	for k, l := range net.Layers {
+		in, z, out := net.in[k], net.z[k], net.in[k+1]
+		for i, row := range l.W {
+			sum := l.B[i]
+			for j, w := range row {
+				sum += w * in[j]
+			}
+			z[i] = sum
+			if k < last {
+				out[i] = net.act.f(sum)
+			} else {
+				out[i] = sum
+			}
+		}
+	}
+	return net.in[len(net.in)-1]
+}
+
+// Add the gradients of the parameters, given the gradient of a loss with
+// respect to the outputs of the last call to Forward, to those accumulated
+// since the last call to ZeroGrad.
+func (net *MLP) Backward(gradOut []float64) {
+	last := len(net.Layers) - 1
+	copy(net.delta[last], gradOut)
+	for k := last; k >= 0; k-- {
+		l, g, in, delta := net.Layers[k], net.grads[k], net.in[k], net.delta[k]
+		for i, d := range delta {
+			if d == 0.0 {
+				continue
+			}
+			g.B[i] += d
+			for j, x := range in {
+				g.W[i][j] += d * x
+			}
+		}
+		if k == 0 {
+			break
+		}
+
+		// the gradient with respect to the layer's inputs, through the
+		// activation of the layer before
+		prev := net.delta[k-1]
+		for j := range prev {
+			sum := 0.0
+			for i, d := range delta {
+				sum += l.W[i][j] * d
+			}
+			prev[j] = sum * net.act.df(net.z[k-1][j])
+		}
+	}
+}
+
+// reset the accumulated gradients to zero
+func (net *MLP) ZeroGrad() {
+	for _, g := range net.grads {
+		for i := range g.W {
+			g.B[i] = 0.0
+			for j := range g.W[i] {
+				g.W[i][j] = 0.0
+			}
+		}
+	}
+}
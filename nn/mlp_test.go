@@ -0,0 +1,88 @@
+package nn
+
+import (
+	"math"
+	"testing"
+
+	"github.com/deong/gorl/random"
+)
+
+// compare the gradients from Backward with finite differences of the loss
+// sum(c_i y_i), for each activation
+func TestBackward(t *testing.T) {
+	x := []float64{0.3, -0.7, 0.5}
+	c := []float64{1.0, -2.0}
+	loss := func(net *MLP) float64 {
+		y := net.Forward(x)
+		return c[0]*y[0] + c[1]*y[1]
+	}
+	for _, act := range []string{"relu", "tanh"} {
+		net, err := NewMLP([]int{3, 5, 4, 2}, act, random.NewRand(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k := range net.Layers {
+			for i := range net.Layers[k].B {
+				net.Layers[k].B[i] = 0.1
+			}
+		}
+		net.Forward(x)
+		net.Backward(c)
+
+		const h = 1e-6
+		for k, l := range net.Layers {
+			for i := range l.W {
+				for j := range l.W[i] {
+					w := l.W[i][j]
+					l.W[i][j] = w + h
+					up := loss(net)
+					l.W[i][j] = w - h
+					down := loss(net)
+					l.W[i][j] = w
+					if numeric := (up - down) / (2 * h); math.Abs(numeric-net.grads[k].W[i][j]) > 1e-6 {
+						t.Errorf("%v: gradient of W[%v][%v][%v] = %v, expected %v\n",
+							act, k, i, j, net.grads[k].W[i][j], numeric)
+					}
+				}
+			}
+		}
+	}
+}
+
+// Adam fits a small network to a smooth function
+func TestAdam(t *testing.T) {
+	rng := random.NewRand(5)
+	net, err := NewMLP([]int{1, 16, 1}, "tanh", rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := NewAdam(net, 0.01)
+	f := func(x float64) float64 { return math.Sin(3.0 * x) }
+	mse := func() float64 {
+		sum := 0.0
+		for x := -1.0; x <= 1.0; x += 0.1 {
+			d := net.Forward([]float64{x})[0] - f(x)
+			sum += d * d
+		}
+		return sum / 21.0
+	}
+	before := mse()
+	grad := make([]float64, 1)
+	for step := 0; step < 2000; step++ {
+		for n := 0; n < 8; n++ {
+			x := 2.0*rng.Float64() - 1.0
+			grad[0] = net.Forward([]float64{x})[0] - f(x)
+			net.Backward(grad)
+		}
+		opt.Step(net, 1.0/8)
+	}
+	if after := mse(); after > 0.01 || after > before/10 {
+		t.Errorf("Mean squared error %v after training, from %v\n", after, before)
+	}
+
+	// a clone computes the same function
+	clone := net.Clone()
+	if y, z := net.Forward([]float64{0.2})[0], clone.Forward([]float64{0.2})[0]; y != z {
+		t.Errorf("Clone computes %v, expected %v\n", z, y)
+	}
+}
@@ -0,0 +1,89 @@
+// Package random provides the serializable generators used throughout gorl,
+// so that runs are reproducible from a single seed and can be checkpointed.
+package random
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/deong/gorl/config"
+)
+
+// Source is a splitmix64 generator implementing rand.Source64. Unlike the
+// sources in math/rand, its entire state is a single word, so it can be saved
+// in a checkpoint and restored exactly.
+type Source struct {
+	state uint64
+}
+
+func NewSource(seed int64) *Source {
+	return &Source{uint64(seed)}
+}
+
+func (src *Source) Seed(seed int64) {
+	src.state = uint64(seed)
+}
+
+func (src *Source) Uint64() uint64 {
+	src.state += 0x9e3779b97f4a7c15
+	z := src.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (src *Source) Int63() int64 {
+	return int64(src.Uint64() >> 1)
+}
+
+// Offsets used to derive the seeds of the components of a run from the seed
+// of the run as a whole.
+const (
+	EnvironmentStream = 1
+	LearnerStream     = 2
+	EvaluationStream  = 3
+)
+
+// derive the seed for one component of a run from the seed of the whole run.
+// Each stream gets its own generator, so that, e.g., changing how many random
+// numbers the learner draws does not change the start states the environment
+// produces.
+func StreamSeed(seed int64, stream uint64) int64 {
+	src := NewSource(seed)
+	src.state += stream * 0xd1b54a32d192ed03
+	return int64(src.Uint64())
+}
+
+// return the seed configured for the run, or a seed taken from the clock if
+// none was given
+func RunSeed(cfg *config.Config) (seed int64, configured bool, err error) {
+	s, err := cfg.Int("learning", "seed")
+	if config.IsMissing(err) {
+		return time.Now().UnixNano(), false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	return int64(s), true, nil
+}
+
+// Rand is a *rand.Rand that remembers the Source it draws from, so that the
+// position of the generator can be read back and restored later.
+type Rand struct {
+	*rand.Rand
+	src *Source
+}
+
+func NewRand(seed int64) *Rand {
+	src := NewSource(seed)
+	return &Rand{rand.New(src), src}
+}
+
+// return the current state of the generator
+func (r *Rand) State() uint64 {
+	return r.src.state
+}
+
+// restore the generator to a state previously returned by State
+func (r *Rand) SetState(state uint64) {
+	r.src.state = state
+}
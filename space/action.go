@@ -1,4 +1,4 @@
-package main
+package space
 
 // Actions in gorl are represented as a floating point action value along with
 // an integer id. Like states, the ids should be able to serve as indices into
@@ -0,0 +1,75 @@
+package space
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/deong/gorl/config"
+)
+
+// the number of lattice points per feature when state_grid is not set
+const DefaultGridPoints = 10
+
+// return the state_grid used when none is configured for a problem with the
+// given number of features
+func DefaultStateGrid(nFeatures int) string {
+	return strings.TrimSpace(strings.Repeat(fmt.Sprintf("%v ", DefaultGridPoints), nFeatures))
+}
+
+// Build the lattice of states described by the state_grid parameter, which
+// gives the number of evenly spaced points along each of the features (e.g.,
+// those of an environment), DefaultGridPoints by default.
+func BuildStateSpace(cfg *config.Config, featureRanges []Range) ([]State, error) {
+	grid, err := StateGrid(cfg, featureRanges)
+	if err != nil {
+		return nil, err
+	}
+	return BuildLattice(grid), nil
+}
+
+// Return the evenly spaced points along each of the features described by
+// the state_grid parameter, from which BuildStateSpace builds its lattice.
+func StateGrid(cfg *config.Config, featureRanges []Range) ([][]float64, error) {
+	nPoints, err := cfg.IntArray("environment", "state_grid")
+	if config.IsMissing(err) {
+		nPoints, err = make([]int, len(featureRanges)), nil
+		for fi := range nPoints {
+			nPoints[fi] = DefaultGridPoints
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(nPoints) != len(featureRanges) {
+		return nil, &config.ParamError{Section: "environment", Key: "state_grid",
+			Err: fmt.Errorf("%v entries given but the problem has %v features", len(nPoints), len(featureRanges))}
+	}
+	grid := make([][]float64, len(nPoints))
+	for fi := range featureRanges {
+		if nPoints[fi] < 2 {
+			return nil, &config.ParamError{Section: "environment", Key: "state_grid",
+				Err: fmt.Errorf("entry %v is %v; each feature needs at least 2 points", fi+1, nPoints[fi])}
+		}
+		grid[fi] = Linspace(featureRanges[fi].Min, featureRanges[fi].Max, nPoints[fi])
+	}
+	return grid, nil
+}
+
+// Build the evenly spaced set of actions in actionRange described by the
+// action_grid parameter.
+func BuildActionSpace(cfg *config.Config, actionRange Range) ([]Action, error) {
+	aPoints, err := cfg.Uint("environment", "action_grid")
+	if err != nil {
+		return nil, err
+	}
+	if aPoints < 2 {
+		return nil, &config.ParamError{Section: "environment", Key: "action_grid", Err: fmt.Errorf("at least 2 actions are needed, not %v", aPoints)}
+	}
+	aGrid := Linspace(actionRange.Min, actionRange.Max, int(aPoints))
+	actions := make([]Action, len(aGrid))
+	for ai := range aGrid {
+		actions[ai].Id = uint(ai)
+		actions[ai].Val = aGrid[ai]
+	}
+	return actions, nil
+}
@@ -0,0 +1,36 @@
+package space
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/deong/gorl/config"
+)
+
+func TestBuildStateSpace(t *testing.T) {
+	features := []Range{{Min: -1.0, Max: 1.0}, {Min: 0.0, Max: 2.0}}
+
+	// DefaultGridPoints along each feature when state_grid is not set
+	cfg := config.New()
+	states, err := BuildStateSpace(cfg, features)
+	if err != nil {
+		t.Fatalf("Unexpected error building the default state space: %v\n", err)
+	}
+	if len(states) != DefaultGridPoints*DefaultGridPoints {
+		t.Errorf("Expected %v states, found %v\n", DefaultGridPoints*DefaultGridPoints, len(states))
+	}
+
+	cfg.Set("environment", "state_grid", "3 2")
+	if states, err = BuildStateSpace(cfg, features); err != nil || len(states) != 6 {
+		t.Errorf("Expected 6 states, found %v (%v)\n", len(states), err)
+	}
+
+	// one entry is needed per feature, each with at least two points
+	for _, grid := range []string{"5 5 5", "5 1"} {
+		cfg.Set("environment", "state_grid", grid)
+		if _, err = BuildStateSpace(cfg, features); err == nil ||
+			!strings.Contains(err.Error(), "[environment] state_grid") {
+			t.Errorf("Expected an error naming state_grid for '%v', got %v\n", grid, err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Package space defines the states and actions of reinforcement learning
+// problems and the lattices used to discretize continuous state spaces.
+package space
+
+import (
+	"math"
+)
+
+// Each parameter has a min and max value.
+type Range struct {
+	Min float64
+	Max float64
+}
+
+// States in gorl are represented by an array of coordinate values along with an
+// integer id. The ids should be unique across the entire state space, and
+// should serve as indices into an array of states (i.e., they should span the
+// range [0, n-1].
+type State struct {
+	Id   uint
+	Vals []float64
+}
+
+func MakeState(dim uint) (s State) {
+	s = State{}
+	s.Vals = make([]float64, dim)
+	return
+}
+
+// compute the distance between two states
+func EuclideanDistance(s1, s2 *State) (dist float64) {
+	dist = 0.0
+	for i := range s1.Vals {
+		dist += (s1.Vals[i] - s2.Vals[i]) * (s1.Vals[i] - s2.Vals[i])
+	}
+	dist = math.Sqrt(dist)
+	return
+}
+
+// return the id of the state in the list that lies nearest to s
+func NearestState(states []State, s *State) uint {
+	// TODO: do a more efficient calculation to replace this search
+	idOfNearest := 0
+	distToNearest := math.MaxFloat64
+	for i := range states {
+		currentDist := EuclideanDistance(s, &states[i])
+		if currentDist < distToNearest {
+			idOfNearest = i
+			distToNearest = currentDist
+		}
+	}
+	return states[idOfNearest].Id
+}